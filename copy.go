@@ -0,0 +1,122 @@
+package folder
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KOMKZ/go-yogan-domain-folder/model"
+)
+
+// defaultCopyLabel 复制重名时的默认后缀标签
+const defaultCopyLabel = "copy"
+
+// CopyOptions 复制选项
+type CopyOptions struct {
+	ExcludeDescendants bool   // 只复制自身，不复制子孙
+	CopyLabel          string // 重名时的后缀标签，为空时使用 "copy"，生成 "名称 (copy)"、"名称 (copy 2)"...
+}
+
+// CopyResult 复制结果
+type CopyResult struct {
+	Folder *model.Folder `json:"folder"` // 复制出的顶层文件夹
+	IDMap  map[uint]uint `json:"idMap"`  // 源节点 ID -> 新节点 ID
+}
+
+// CopyFolder 将源文件夹（默认连同整棵子树）复制到目标父节点下
+// 新节点追加到目标父节点末尾，子树内保持原有兄弟顺序；与目标父节点下已有名称冲突时重命名顶层节点
+func (s *Service) CopyFolder(ctx context.Context, sourceID uint, targetParentID *uint, opts *CopyOptions) (*CopyResult, error) {
+	if opts == nil {
+		opts = &CopyOptions{}
+	}
+
+	var result *CopyResult
+	err := s.repo.WithTx(ctx, func(repo Repository) error {
+		var err error
+		result, err = s.copyFolder(ctx, repo, sourceID, targetParentID, opts)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// copyFolder 在给定仓储上复制文件夹
+func (s *Service) copyFolder(ctx context.Context, repo Repository, sourceID uint, targetParentID *uint, opts *CopyOptions) (*CopyResult, error) {
+	source, err := repo.FindByID(ctx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	targetDepth := 0
+	if targetParentID != nil {
+		parent, err := repo.FindByID(ctx, *targetParentID)
+		if err != nil {
+			return nil, ErrParentNotFound
+		}
+		targetDepth = parent.Depth + 1
+	}
+
+	// 先取出子树快照，复制到自身子树下时不会重复复制新节点
+	nodes := []*model.Folder{source}
+	if !opts.ExcludeDescendants {
+		nodes, err = repo.FindByPath(ctx, source.Path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 检查深度限制
+	if s.config.MaxDepth > 0 {
+		maxChildDepth := 0
+		for _, n := range nodes {
+			if relativeDepth := n.Depth - source.Depth; relativeDepth > maxChildDepth {
+				maxChildDepth = relativeDepth
+			}
+		}
+		if targetDepth+maxChildDepth >= s.config.MaxDepth {
+			return nil, ErrMaxDepthExceeded
+		}
+	}
+
+	label := opts.CopyLabel
+	if label == "" {
+		label = defaultCopyLabel
+	}
+	name, err := uniqueNameWith(ctx, repo, source.Name, targetParentID, nil, func(name string, n int) string {
+		if n == 1 {
+			return fmt.Sprintf("%s (%s)", name, label)
+		}
+		return fmt.Sprintf("%s (%s %d)", name, label, n)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 按深度、兄弟顺序逐个创建，父节点总是先于子节点创建
+	idMap := make(map[uint]uint, len(nodes))
+	var root *model.Folder
+	for _, n := range nodes {
+		input := &CreateFolderInput{Name: n.Name, ParentID: targetParentID}
+		if n.ID == source.ID {
+			input.Name = name
+		} else {
+			newParentID, ok := idMap[*n.ParentID]
+			if !ok {
+				continue // 父节点不在快照中（数据不一致），跳过
+			}
+			input.ParentID = &newParentID
+		}
+
+		created, err := s.createFolder(ctx, repo, input)
+		if err != nil {
+			return nil, err
+		}
+		idMap[n.ID] = created.ID
+		if n.ID == source.ID {
+			root = created
+		}
+	}
+
+	return &CopyResult{Folder: root, IDMap: idMap}, nil
+}
@@ -0,0 +1,134 @@
+package folder
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCopyFolder_Subtree 测试复制整棵子树
+func TestCopyFolder_Subtree(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	root := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &root.ID)
+	mustCreateFolder(t, svc, "并发", &golang.ID)
+	mustCreateFolder(t, svc, "Web", &golang.ID)
+	mustCreateFolder(t, svc, "Rust", &root.ID)
+	target := mustCreateFolder(t, svc, "归档", nil)
+
+	result, err := svc.CopyFolder(ctx, root.ID, &target.ID, nil)
+	require.NoError(t, err)
+	assert.Len(t, result.IDMap, 5)
+
+	copied := result.Folder
+	assert.Equal(t, "技术", copied.Name)
+	assert.Equal(t, &target.ID, copied.ParentID)
+	assert.Equal(t, 1, copied.Depth)
+	assert.Equal(t, fmt.Sprintf("/%d/%d/", target.ID, copied.ID), copied.Path)
+
+	// 子树结构与兄弟顺序保持不变
+	assert.Equal(t, []string{"Go", "Rust"}, siblingNames(t, repo, &copied.ID))
+	newGoID := result.IDMap[golang.ID]
+	assert.NotEqual(t, golang.ID, newGoID)
+	assert.Equal(t, []string{"并发", "Web"}, siblingNames(t, repo, &newGoID))
+
+	newGo, err := repo.FindByID(ctx, newGoID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, newGo.Depth)
+	assert.Equal(t, fmt.Sprintf("%s%d/", copied.Path, newGoID), newGo.Path)
+
+	// 源子树不受影响
+	assert.Equal(t, []string{"Go", "Rust"}, siblingNames(t, repo, &root.ID))
+}
+
+// TestCopyFolder_RenameOnConflict 测试同级重名时重命名
+func TestCopyFolder_RenameOnConflict(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	root := mustCreateFolder(t, svc, "技术", nil)
+
+	first, err := svc.CopyFolder(ctx, root.ID, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "技术 (copy)", first.Folder.Name)
+
+	second, err := svc.CopyFolder(ctx, root.ID, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "技术 (copy 2)", second.Folder.Name)
+
+	third, err := svc.CopyFolder(ctx, root.ID, nil, &CopyOptions{CopyLabel: "副本"})
+	require.NoError(t, err)
+	assert.Equal(t, "技术 (副本)", third.Folder.Name)
+
+	assert.Equal(t, []string{"技术", "技术 (copy)", "技术 (copy 2)", "技术 (副本)"}, siblingNames(t, repo, nil))
+}
+
+// TestCopyFolder_IntoOwnSubtree 测试复制到自身子树下
+func TestCopyFolder_IntoOwnSubtree(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	root := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &root.ID)
+
+	result, err := svc.CopyFolder(ctx, root.ID, &golang.ID, nil)
+	require.NoError(t, err)
+	assert.Len(t, result.IDMap, 2)
+
+	all, err := repo.FindAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 4)
+}
+
+// TestCopyFolder_ExcludeDescendants 测试只复制自身
+func TestCopyFolder_ExcludeDescendants(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	root := mustCreateFolder(t, svc, "技术", nil)
+	mustCreateFolder(t, svc, "Go", &root.ID)
+
+	result, err := svc.CopyFolder(ctx, root.ID, nil, &CopyOptions{ExcludeDescendants: true})
+	require.NoError(t, err)
+	assert.Len(t, result.IDMap, 1)
+	assert.Empty(t, siblingNames(t, repo, &result.Folder.ID))
+}
+
+// TestCopyFolder_MaxDepthExceeded 测试复制后超过最大深度
+func TestCopyFolder_MaxDepthExceeded(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewServiceWithConfig(repo, ServiceConfig{MaxDepth: 3})
+	ctx := context.Background()
+
+	root := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &root.ID)
+	mustCreateFolder(t, svc, "并发", &golang.ID)
+	other := mustCreateFolder(t, svc, "生活", nil)
+
+	_, err := svc.CopyFolder(ctx, root.ID, &other.ID, nil)
+	assert.ErrorIs(t, err, ErrMaxDepthExceeded)
+
+	// 失败时不留下部分复制的节点
+	assert.Empty(t, siblingNames(t, repo, &other.ID))
+}
+
+// TestCopyFolder_TargetNotFound 测试目标父节点不存在
+func TestCopyFolder_TargetNotFound(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+
+	root := mustCreateFolder(t, svc, "技术", nil)
+	missing := uint(999)
+
+	_, err := svc.CopyFolder(context.Background(), root.ID, &missing, nil)
+	assert.ErrorIs(t, err, ErrParentNotFound)
+}
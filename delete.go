@@ -0,0 +1,146 @@
+package folder
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/KOMKZ/go-yogan-domain-folder/model"
+)
+
+// DeleteStrategy 删除策略，决定如何处理被删除文件夹的子孙节点
+// config 为服务配置，策略需要为节点分配排序值时使用
+type DeleteStrategy interface {
+	Delete(ctx context.Context, repo Repository, config ServiceConfig, folder *model.Folder) (*DeleteResult, error)
+}
+
+// 内置删除策略
+var (
+	// DeleteReject 存在子节点时拒绝删除（默认）
+	DeleteReject DeleteStrategy = rejectStrategy{}
+	// DeleteCascade 级联软删除整棵子树
+	DeleteCascade DeleteStrategy = cascadeStrategy{}
+	// DeleteReparent 删除自身，子节点上移到祖父节点下
+	DeleteReparent DeleteStrategy = reparentStrategy{}
+)
+
+// DeleteOptions 删除选项
+type DeleteOptions struct {
+	Strategy DeleteStrategy // 为空时使用 DeleteReject
+}
+
+// DeleteResult 删除结果
+type DeleteResult struct {
+	DeletedIDs    []uint `json:"deletedIds"`    // 被删除的节点
+	ReparentedIDs []uint `json:"reparentedIds"` // 被移动到新父节点下的节点
+}
+
+// DeleteFolderWithOptions 按指定策略删除文件夹
+func (s *Service) DeleteFolderWithOptions(ctx context.Context, id uint, opts *DeleteOptions) (*DeleteResult, error) {
+	strategy := DeleteReject
+	if opts != nil && opts.Strategy != nil {
+		strategy = opts.Strategy
+	}
+
+	var result *DeleteResult
+	err := s.repo.WithTx(ctx, func(repo Repository) error {
+		folder, err := repo.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		result, err = strategy.Delete(ctx, repo, s.config, folder)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// rejectStrategy 仅允许删除叶子节点
+type rejectStrategy struct{}
+
+func (rejectStrategy) Delete(ctx context.Context, repo Repository, _ ServiceConfig, folder *model.Folder) (*DeleteResult, error) {
+	hasChildren, err := repo.HasChildren(ctx, folder.ID)
+	if err != nil {
+		return nil, err
+	}
+	if hasChildren {
+		return nil, ErrHasChildren
+	}
+
+	if err := repo.Delete(ctx, folder.ID); err != nil {
+		return nil, err
+	}
+	return &DeleteResult{DeletedIDs: []uint{folder.ID}}, nil
+}
+
+// cascadeStrategy 通过物化路径删除整棵子树
+type cascadeStrategy struct{}
+
+func (cascadeStrategy) Delete(ctx context.Context, repo Repository, _ ServiceConfig, folder *model.Folder) (*DeleteResult, error) {
+	descendants, err := repo.FindByPath(ctx, folder.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(descendants))
+	for _, d := range descendants {
+		ids = append(ids, d.ID)
+	}
+	if err := repo.DeleteByIDs(ctx, ids); err != nil {
+		return nil, err
+	}
+	return &DeleteResult{DeletedIDs: ids}, nil
+}
+
+// reparentStrategy 删除自身并将直接子节点挂到祖父节点下
+// 子节点按原有顺序追加到祖父节点的子节点末尾，与祖父节点下已有名称冲突时返回 ErrDuplicateName
+type reparentStrategy struct{}
+
+func (reparentStrategy) Delete(ctx context.Context, repo Repository, config ServiceConfig, folder *model.Folder) (*DeleteResult, error) {
+	children, err := repo.FindChildren(ctx, folder.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 先删除自身，避免与自身名称冲突
+	if err := repo.Delete(ctx, folder.ID); err != nil {
+		return nil, err
+	}
+
+	result := &DeleteResult{DeletedIDs: []uint{folder.ID}}
+	if len(children) == 0 {
+		return result, nil
+	}
+
+	// 整体上移一层：/.../{folder.ID}/x/ -> /.../x/
+	parentPath := strings.TrimSuffix(folder.Path, fmt.Sprintf("%d/", folder.ID))
+	for _, child := range children {
+		oldChildPath := child.Path
+		exists, err := repo.ExistsByNameAndParent(ctx, child.Name, folder.ParentID, &child.ID)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, ErrDuplicateName
+		}
+
+		child.ParentID = folder.ParentID
+		child.Path = parentPath + fmt.Sprintf("%d/", child.ID)
+		child.Depth = folder.Depth
+		if err := appendOrder(ctx, repo, config, child, folder.ParentID); err != nil {
+			return nil, err
+		}
+		if err := repo.Update(ctx, child); err != nil {
+			return nil, err
+		}
+		// 更新更深层的子孙节点；按子节点逐个更新，闭包表等实现中子孙已随子节点脱离被删除的节点
+		if err := repo.UpdateChildrenPathAndDepth(ctx, oldChildPath, child.Path, -1); err != nil {
+			return nil, err
+		}
+		result.ReparentedIDs = append(result.ReparentedIDs, child.ID)
+	}
+
+	return result, nil
+}
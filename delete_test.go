@@ -0,0 +1,130 @@
+package folder
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/KOMKZ/go-yogan-domain-folder/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mustCreateFolder 创建文件夹，失败时终止测试
+func mustCreateFolder(t *testing.T, svc *Service, name string, parentID *uint) *model.Folder {
+	t.Helper()
+	f, err := svc.CreateFolder(context.Background(), &CreateFolderInput{Name: name, ParentID: parentID})
+	require.NoError(t, err)
+	return f
+}
+
+// TestDeleteFolderWithOptions_DefaultRejects 测试默认策略拒绝删除非叶子节点
+func TestDeleteFolderWithOptions_DefaultRejects(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	root := mustCreateFolder(t, svc, "技术", nil)
+	mustCreateFolder(t, svc, "Go", &root.ID)
+
+	result, err := svc.DeleteFolderWithOptions(ctx, root.ID, nil)
+	assert.ErrorIs(t, err, ErrHasChildren)
+	assert.Nil(t, result)
+}
+
+// TestDeleteFolderWithOptions_Cascade 测试级联删除子树
+func TestDeleteFolderWithOptions_Cascade(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	root := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &root.ID)
+	conc := mustCreateFolder(t, svc, "并发", &golang.ID)
+	other := mustCreateFolder(t, svc, "生活", nil)
+
+	result, err := svc.DeleteFolderWithOptions(ctx, root.ID, &DeleteOptions{Strategy: DeleteCascade})
+	require.NoError(t, err)
+	assert.Equal(t, []uint{root.ID, golang.ID, conc.ID}, result.DeletedIDs)
+	assert.Empty(t, result.ReparentedIDs)
+
+	all, err := repo.FindAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, other.ID, all[0].ID)
+}
+
+// TestDeleteFolderWithOptions_Reparent 测试子节点上移到祖父节点
+func TestDeleteFolderWithOptions_Reparent(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	root := mustCreateFolder(t, svc, "技术", nil)
+	mustCreateFolder(t, svc, "Rust", &root.ID)
+	golang := mustCreateFolder(t, svc, "Go", &root.ID)
+	conc := mustCreateFolder(t, svc, "并发", &golang.ID)
+	sync := mustCreateFolder(t, svc, "sync", &conc.ID)
+	web := mustCreateFolder(t, svc, "Web", &golang.ID)
+
+	result, err := svc.DeleteFolderWithOptions(ctx, golang.ID, &DeleteOptions{Strategy: DeleteReparent})
+	require.NoError(t, err)
+	assert.Equal(t, []uint{golang.ID}, result.DeletedIDs)
+	assert.Equal(t, []uint{conc.ID, web.ID}, result.ReparentedIDs)
+
+	children, err := repo.FindChildren(ctx, root.ID)
+	require.NoError(t, err)
+	names := make([]string, 0, len(children))
+	for _, c := range children {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"Rust", "并发", "Web"}, names)
+
+	movedConc, err := repo.FindByID(ctx, conc.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, movedConc.Depth)
+	assert.Equal(t, fmt.Sprintf("%s%d/", root.Path, conc.ID), movedConc.Path)
+
+	movedSync, err := repo.FindByID(ctx, sync.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, movedSync.Depth)
+	assert.Equal(t, fmt.Sprintf("%s%d/", movedConc.Path, sync.ID), movedSync.Path)
+}
+
+// TestDeleteFolderWithOptions_ReparentRoot 测试删除根节点时子节点成为根节点
+func TestDeleteFolderWithOptions_ReparentRoot(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	root := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &root.ID)
+
+	_, err := svc.DeleteFolderWithOptions(ctx, root.ID, &DeleteOptions{Strategy: DeleteReparent})
+	require.NoError(t, err)
+
+	moved, err := repo.FindByID(ctx, golang.ID)
+	require.NoError(t, err)
+	assert.Nil(t, moved.ParentID)
+	assert.Equal(t, 0, moved.Depth)
+	assert.Equal(t, fmt.Sprintf("/%d/", golang.ID), moved.Path)
+}
+
+// TestDeleteFolderWithOptions_ReparentConflict 测试上移时名称冲突整体回滚
+func TestDeleteFolderWithOptions_ReparentConflict(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	root := mustCreateFolder(t, svc, "技术", nil)
+	mustCreateFolder(t, svc, "Go", &root.ID)
+	lang := mustCreateFolder(t, svc, "语言", &root.ID)
+	mustCreateFolder(t, svc, "Go", &lang.ID)
+
+	_, err := svc.DeleteFolderWithOptions(ctx, lang.ID, &DeleteOptions{Strategy: DeleteReparent})
+	assert.ErrorIs(t, err, ErrDuplicateName)
+
+	kept, err := repo.FindByID(ctx, lang.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "语言", kept.Name)
+}
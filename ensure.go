@@ -0,0 +1,68 @@
+package folder
+
+import (
+	"context"
+	"errors"
+
+	"github.com/KOMKZ/go-yogan-domain-folder/model"
+)
+
+// ensurePathRetries 并发创建冲突时的最大重试次数
+const ensurePathRetries = 3
+
+// EnsurePath 确保 parentID 下存在 names 指定的路径（类似 mkdir -p），返回末级文件夹
+// 已存在的同名文件夹被复用，只创建缺失的尾部；整个过程在一个事务中完成
+// 并发调用方同时创建同一节点时，落败方在同级唯一索引上得到 ErrDuplicateName，事务回滚后重试并复用对方创建的节点
+func (s *Service) EnsurePath(ctx context.Context, parentID *uint, names []string) (*model.Folder, error) {
+	if len(names) == 0 {
+		return nil, ErrInvalidName
+	}
+	for _, name := range names {
+		if err := s.validateName(name); err != nil {
+			return nil, err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		var leaf *model.Folder
+		err := s.repo.WithTx(ctx, func(repo Repository) error {
+			var err error
+			leaf, err = s.ensurePath(ctx, repo, parentID, names)
+			return err
+		})
+		if errors.Is(err, ErrDuplicateName) && attempt < ensurePathRetries {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return leaf, nil
+	}
+}
+
+// ensurePath 逐级查找或创建
+func (s *Service) ensurePath(ctx context.Context, repo Repository, parentID *uint, names []string) (*model.Folder, error) {
+	if parentID != nil {
+		if _, err := repo.FindByID(ctx, *parentID); err != nil {
+			return nil, ErrParentNotFound
+		}
+	}
+
+	var current *model.Folder
+	for _, name := range names {
+		existing, err := repo.FindByNameAndParent(ctx, name, parentID)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+		if existing != nil {
+			current = existing
+		} else {
+			current, err = s.createFolder(ctx, repo, &CreateFolderInput{Name: name, ParentID: parentID})
+			if err != nil {
+				return nil, err
+			}
+		}
+		parentID = &current.ID
+	}
+	return current, nil
+}
@@ -0,0 +1,144 @@
+package folder
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/KOMKZ/go-yogan-domain-folder/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// racingRepository 模拟并发：前 misses 次按名称查找时假装节点不存在，如同另一调用方刚刚提交
+type racingRepository struct {
+	Repository
+	misses *int
+}
+
+func (r *racingRepository) WithTx(ctx context.Context, fn func(repo Repository) error) error {
+	return r.Repository.WithTx(ctx, func(repo Repository) error {
+		return fn(&racingRepository{Repository: repo, misses: r.misses})
+	})
+}
+
+func (r *racingRepository) FindByNameAndParent(ctx context.Context, name string, parentID *uint) (*model.Folder, error) {
+	if *r.misses > 0 {
+		*r.misses--
+		return nil, ErrNotFound
+	}
+	return r.Repository.FindByNameAndParent(ctx, name, parentID)
+}
+
+// TestEnsurePath_CreatesMissingTail 测试复用已有节点并只创建缺失部分
+func TestEnsurePath_CreatesMissingTail(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	a := mustCreateFolder(t, svc, "A", nil)
+	mustCreateFolder(t, svc, "X", &a.ID)
+
+	leaf, err := svc.EnsurePath(ctx, nil, []string{"A", "B", "C"})
+	require.NoError(t, err)
+	assert.Equal(t, "C", leaf.Name)
+	assert.Equal(t, 2, leaf.Depth)
+
+	assert.Equal(t, []string{"A"}, siblingNames(t, repo, nil))
+	assert.Equal(t, []string{"X", "B"}, siblingNames(t, repo, &a.ID))
+
+	// 再次调用直接返回已有节点
+	again, err := svc.EnsurePath(ctx, nil, []string{"A", "B", "C"})
+	require.NoError(t, err)
+	assert.Equal(t, leaf.ID, again.ID)
+}
+
+// TestEnsurePath_UnderParent 测试在指定父节点下创建
+func TestEnsurePath_UnderParent(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	root := mustCreateFolder(t, svc, "文章", nil)
+	leaf, err := svc.EnsurePath(ctx, &root.ID, []string{"技术", "Go"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, leaf.Depth)
+	assert.Equal(t, []string{"技术"}, siblingNames(t, repo, &root.ID))
+
+	missing := uint(999)
+	_, err = svc.EnsurePath(ctx, &missing, []string{"技术"})
+	assert.ErrorIs(t, err, ErrParentNotFound)
+}
+
+// TestEnsurePath_Validation 测试名称与深度校验，失败时不留下部分路径
+func TestEnsurePath_Validation(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewServiceWithConfig(repo, ServiceConfig{MaxDepth: 2})
+	ctx := context.Background()
+
+	_, err := svc.EnsurePath(ctx, nil, nil)
+	assert.ErrorIs(t, err, ErrInvalidName)
+	_, err = svc.EnsurePath(ctx, nil, []string{"A", " "})
+	assert.ErrorIs(t, err, ErrInvalidName)
+
+	_, err = svc.EnsurePath(ctx, nil, []string{"A", "B", "C"})
+	assert.ErrorIs(t, err, ErrMaxDepthExceeded)
+	assert.Empty(t, siblingNames(t, repo, nil))
+}
+
+// TestEnsurePath_RetriesOnRace 测试并发创建冲突后重试并复用对方创建的节点
+func TestEnsurePath_RetriesOnRace(t *testing.T) {
+	base := NewGormRepository(newTestDB(t), testTableName)
+	ctx := context.Background()
+
+	a := mustCreateFolder(t, NewService(base), "A", nil)
+
+	misses := 1
+	svc := NewService(&racingRepository{Repository: base, misses: &misses})
+	leaf, err := svc.EnsurePath(ctx, nil, []string{"A", "B"})
+	require.NoError(t, err)
+	assert.Equal(t, &a.ID, leaf.ParentID)
+	assert.Equal(t, []string{"A"}, siblingNames(t, base, nil))
+
+	// 持续冲突时放弃重试
+	misses = ensurePathRetries + 1
+	_, err = svc.EnsurePath(ctx, nil, []string{"A"})
+	assert.ErrorIs(t, err, ErrDuplicateName)
+}
+
+// TestEnsurePath_Concurrent 测试多个调用方并发确保同一路径
+func TestEnsurePath_Concurrent(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "folder.db") + "?_busy_timeout=5000&_txlock=immediate"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.Table(testTableName).AutoMigrate(&model.Folder{}))
+	repo := NewGormRepository(db, testTableName)
+	require.NoError(t, repo.MigrateUniqueNameIndex(context.Background()))
+	svc := NewService(repo)
+
+	const callers = 8
+	ids := make([]uint, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			leaf, err := svc.EnsurePath(context.Background(), nil, []string{"A", "B", "C"})
+			if assert.NoError(t, err) {
+				ids[i] = leaf.ID
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for _, id := range ids {
+		assert.Equal(t, ids[0], id)
+	}
+	all, err := repo.FindAll(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+}
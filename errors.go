@@ -73,4 +73,49 @@ var (
 		"该分类下有子分类，请先删除子分类",
 		http.StatusBadRequest,
 	))
+
+	// ErrScopeMissing 缺少作用域
+	ErrScopeMissing = errcode.Register(errcode.New(
+		ModuleFolder, 1008,
+		"folder",
+		"error.folder.scope_missing",
+		"缺少分类作用域",
+		http.StatusBadRequest,
+	))
+
+	// ErrVersionConflict 版本冲突
+	ErrVersionConflict = errcode.Register(errcode.New(
+		ModuleFolder, 1009,
+		"folder",
+		"error.folder.version_conflict",
+		"分类已被他人修改，请刷新后重试",
+		http.StatusConflict,
+	))
+
+	// ErrInvalidAnchor 无效的定位锚点
+	ErrInvalidAnchor = errcode.Register(errcode.New(
+		ModuleFolder, 1010,
+		"folder",
+		"error.folder.invalid_anchor",
+		"目标位置的参照分类无效",
+		http.StatusBadRequest,
+	))
+
+	// ErrImportInvalid 导入数据校验失败
+	ErrImportInvalid = errcode.Register(errcode.New(
+		ModuleFolder, 1011,
+		"folder",
+		"error.folder.import_invalid",
+		"导入数据校验失败",
+		http.StatusBadRequest,
+	))
+
+	// ErrInvalidCursor 无效的分页游标
+	ErrInvalidCursor = errcode.Register(errcode.New(
+		ModuleFolder, 1012,
+		"folder",
+		"error.folder.invalid_cursor",
+		"分页游标无效",
+		http.StatusBadRequest,
+	))
 )
@@ -0,0 +1,194 @@
+// Package exporter 将文件夹树序列化为 JSON、YAML、CSV 或 OPML
+// 输入为 Service.GetTree / GetSubTree 的结果，输出按节点逐个写入 io.Writer，不在内存中另建副本
+package exporter
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	folder "github.com/KOMKZ/go-yogan-domain-folder"
+	"github.com/KOMKZ/go-yogan-domain-folder/model"
+)
+
+// Format 导出格式
+type Format string
+
+const (
+	FormatJSON Format = "json" // 嵌套 JSON，可直接用 ImportTree 导回
+	FormatYAML Format = "yaml" // 嵌套 YAML，结构同 JSON
+	FormatCSV  Format = "csv"  // 扁平 CSV，每行一个节点，path 列为完整名称路径
+	FormatOPML Format = "opml" // OPML 2.0 大纲
+)
+
+// ErrUnsupportedFormat 不支持的导出格式
+var ErrUnsupportedFormat = errors.New("exporter: unsupported format")
+
+// Options 导出选项
+type Options struct {
+	Separator string // CSV 名称路径分隔符，默认 "/"
+	Title     string // OPML 标题
+}
+
+// Export 按指定格式导出
+func Export(w io.Writer, nodes []*model.FolderNode, format Format, opts *Options) error {
+	if opts == nil {
+		opts = &Options{}
+	}
+	switch format {
+	case FormatJSON:
+		return ExportJSON(w, nodes)
+	case FormatYAML:
+		return ExportYAML(w, nodes)
+	case FormatCSV:
+		return ExportCSV(w, nodes, opts.Separator)
+	case FormatOPML:
+		return ExportOPML(w, nodes, opts.Title)
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+}
+
+// ExportJSON 导出为嵌套 JSON 数组
+func ExportJSON(w io.Writer, nodes []*model.FolderNode) error {
+	bw := bufio.NewWriter(w)
+	writeJSONNodes(bw, nodes)
+	bw.WriteString("\n")
+	return bw.Flush()
+}
+
+// writeJSONNodes 递归写入 JSON 节点数组，写入错误由 bufio.Writer 保留到 Flush 时返回
+func writeJSONNodes(w *bufio.Writer, nodes []*model.FolderNode) {
+	w.WriteByte('[')
+	for i, n := range nodes {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		fmt.Fprintf(w, `{"id":%d,"name":%s,"parentId":%s,"sortOrder":%d,"depth":%d`,
+			n.ID, quote(n.Name), parentIDString(n.ParentID, "null"), n.SortOrder, n.Depth)
+		if len(n.Children) > 0 {
+			w.WriteString(`,"children":`)
+			writeJSONNodes(w, n.Children)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(']')
+}
+
+// ExportYAML 导出为嵌套 YAML 序列
+func ExportYAML(w io.Writer, nodes []*model.FolderNode) error {
+	bw := bufio.NewWriter(w)
+	if len(nodes) == 0 {
+		bw.WriteString("[]\n")
+	}
+	writeYAMLNodes(bw, nodes, "")
+	return bw.Flush()
+}
+
+// writeYAMLNodes 递归写入 YAML 节点序列
+func writeYAMLNodes(w *bufio.Writer, nodes []*model.FolderNode, indent string) {
+	for _, n := range nodes {
+		fmt.Fprintf(w, "%s- id: %d\n", indent, n.ID)
+		fmt.Fprintf(w, "%s  name: %s\n", indent, quote(n.Name))
+		fmt.Fprintf(w, "%s  parentId: %s\n", indent, parentIDString(n.ParentID, "null"))
+		fmt.Fprintf(w, "%s  sortOrder: %d\n", indent, n.SortOrder)
+		fmt.Fprintf(w, "%s  depth: %d\n", indent, n.Depth)
+		if len(n.Children) > 0 {
+			fmt.Fprintf(w, "%s  children:\n", indent)
+			writeYAMLNodes(w, n.Children, indent+"    ")
+		}
+	}
+}
+
+// ExportCSV 导出为扁平 CSV，先序遍历，列为 path,id,parent_id,depth,sort_order
+// 名称中的分隔符按 folder.JoinNamePath 规则转义
+func ExportCSV(w io.Writer, nodes []*model.FolderNode, separator string) error {
+	if separator == "" {
+		separator = "/"
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"path", "id", "parent_id", "depth", "sort_order"}); err != nil {
+		return err
+	}
+
+	var walk func(nodes []*model.FolderNode, names []string) error
+	walk = func(nodes []*model.FolderNode, names []string) error {
+		for _, n := range nodes {
+			path := append(names, n.Name)
+			record := []string{
+				folder.JoinNamePath(path, separator),
+				strconv.FormatUint(uint64(n.ID), 10),
+				parentIDString(n.ParentID, ""),
+				strconv.Itoa(n.Depth),
+				strconv.Itoa(n.SortOrder),
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+			if err := walk(n.Children, path[:len(path):len(path)]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(nodes, nil); err != nil {
+		return err
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// ExportOPML 导出为 OPML 2.0 大纲
+func ExportOPML(w io.Writer, nodes []*model.FolderNode, title string) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(xml.Header)
+	bw.WriteString("<opml version=\"2.0\">\n  <head>\n    <title>")
+	xml.EscapeText(bw, []byte(title))
+	bw.WriteString("</title>\n  </head>\n  <body>\n")
+	writeOPMLNodes(bw, nodes, "    ")
+	bw.WriteString("  </body>\n</opml>\n")
+	return bw.Flush()
+}
+
+// writeOPMLNodes 递归写入 outline 元素
+func writeOPMLNodes(w *bufio.Writer, nodes []*model.FolderNode, indent string) {
+	for _, n := range nodes {
+		w.WriteString(indent)
+		w.WriteString(`<outline text="`)
+		xml.EscapeText(w, []byte(n.Name))
+		fmt.Fprintf(w, `" id="%d"`, n.ID)
+		if len(n.Children) == 0 {
+			w.WriteString("/>\n")
+			continue
+		}
+		w.WriteString(">\n")
+		writeOPMLNodes(w, n.Children, indent+"  ")
+		w.WriteString(indent)
+		w.WriteString("</outline>\n")
+	}
+}
+
+// quote 将字符串编码为 JSON 字符串字面量，同时也是合法的 YAML 双引号标量
+func quote(s string) string {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// parentIDString 格式化父节点 ID，nil 时返回 null
+func parentIDString(parentID *uint, null string) string {
+	if parentID == nil {
+		return null
+	}
+	return strconv.FormatUint(uint64(*parentID), 10)
+}
@@ -0,0 +1,128 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+
+	"github.com/KOMKZ/go-yogan-domain-folder/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// testTree 构建测试用树：技术/{Go/并发, "R&D <x>"}, 生活
+func testTree() []*model.FolderNode {
+	id := func(v uint) *uint { return &v }
+	return []*model.FolderNode{
+		{ID: 1, Name: "技术", Children: []*model.FolderNode{
+			{ID: 2, Name: "Go", ParentID: id(1), Depth: 1, Children: []*model.FolderNode{
+				{ID: 4, Name: "并发", ParentID: id(2), Depth: 2},
+			}},
+			{ID: 3, Name: `R&D "<x>"`, ParentID: id(1), Depth: 1, SortOrder: 1},
+		}},
+		{ID: 5, Name: "生活", SortOrder: 1},
+	}
+}
+
+// decodedNode 解码导出结果用的节点
+type decodedNode struct {
+	ID       uint           `json:"id" yaml:"id"`
+	Name     string         `json:"name" yaml:"name"`
+	ParentID *uint          `json:"parentId" yaml:"parentId"`
+	Children []*decodedNode `json:"children" yaml:"children"`
+}
+
+// assertDecodedTree 校验解码后的树结构
+func assertDecodedTree(t *testing.T, nodes []*decodedNode) {
+	t.Helper()
+	require.Len(t, nodes, 2)
+	assert.Equal(t, "技术", nodes[0].Name)
+	assert.Nil(t, nodes[0].ParentID)
+	require.Len(t, nodes[0].Children, 2)
+	assert.Equal(t, uint(1), *nodes[0].Children[0].ParentID)
+	assert.Equal(t, "并发", nodes[0].Children[0].Children[0].Name)
+	assert.Equal(t, `R&D "<x>"`, nodes[0].Children[1].Name)
+	assert.Equal(t, "生活", nodes[1].Name)
+	assert.Empty(t, nodes[1].Children)
+}
+
+// TestExportJSON 测试导出 JSON
+func TestExportJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Export(&buf, testTree(), FormatJSON, nil))
+
+	var nodes []*decodedNode
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &nodes))
+	assertDecodedTree(t, nodes)
+}
+
+// TestExportYAML 测试导出 YAML
+func TestExportYAML(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Export(&buf, testTree(), FormatYAML, nil))
+
+	var nodes []*decodedNode
+	require.NoError(t, yaml.Unmarshal(buf.Bytes(), &nodes))
+	assertDecodedTree(t, nodes)
+}
+
+// TestExportCSV 测试导出 CSV
+func TestExportCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Export(&buf, testTree(), FormatCSV, &Options{Separator: " > "}))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"path", "id", "parent_id", "depth", "sort_order"},
+		{"技术", "1", "", "0", "0"},
+		{"技术 > Go", "2", "1", "1", "0"},
+		{"技术 > Go > 并发", "4", "2", "2", "0"},
+		{`技术 > R&D "<x>"`, "3", "1", "1", "1"},
+		{"生活", "5", "", "0", "1"},
+	}, records)
+}
+
+// TestExportOPML 测试导出 OPML
+func TestExportOPML(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Export(&buf, testTree(), FormatOPML, &Options{Title: "文件夹 & 分类"}))
+
+	type outline struct {
+		Text     string    `xml:"text,attr"`
+		Outlines []outline `xml:"outline"`
+	}
+	var doc struct {
+		Version string    `xml:"version,attr"`
+		Title   string    `xml:"head>title"`
+		Body    []outline `xml:"body>outline"`
+	}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, "2.0", doc.Version)
+	assert.Equal(t, "文件夹 & 分类", doc.Title)
+	require.Len(t, doc.Body, 2)
+	assert.Equal(t, "技术", doc.Body[0].Text)
+	assert.Equal(t, `R&D "<x>"`, doc.Body[0].Outlines[1].Text)
+	assert.Equal(t, "并发", doc.Body[0].Outlines[0].Outlines[0].Text)
+}
+
+// TestExport_Empty 测试导出空树
+func TestExport_Empty(t *testing.T) {
+	for _, format := range []Format{FormatJSON, FormatYAML} {
+		var buf bytes.Buffer
+		require.NoError(t, Export(&buf, nil, format, nil))
+
+		var nodes []*decodedNode
+		require.NoError(t, yaml.Unmarshal(buf.Bytes(), &nodes), format)
+		assert.Empty(t, nodes)
+	}
+}
+
+// TestExport_UnsupportedFormat 测试不支持的格式
+func TestExport_UnsupportedFormat(t *testing.T) {
+	err := Export(&bytes.Buffer{}, testTree(), Format("xlsx"), nil)
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
@@ -0,0 +1,83 @@
+package folder
+
+import (
+	"context"
+	"strings"
+
+	"github.com/KOMKZ/go-yogan-domain-folder/model"
+)
+
+// TreeFilter 树过滤条件，Predicate 不为 nil 时忽略 Query
+type TreeFilter struct {
+	Query     string                          // 名称关键字，不区分大小写；启用拼音索引时同时匹配全拼与首字母
+	Match     MatchMode                       // 匹配方式，默认子串匹配
+	Predicate func(folder *model.Folder) bool // 自定义匹配条件
+}
+
+// GetFilteredTree 获取过滤后的树：只保留命中节点及其祖先链，命中节点的 Matched 为 true
+// 过滤条件为空时返回完整的树，所有节点均视为命中
+func (s *Service) GetFilteredTree(ctx context.Context, filter *TreeFilter) ([]*model.FolderNode, error) {
+	folders, err := s.repo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	match := s.treeMatcher(filter)
+	matched := make(map[uint]bool)
+	keep := make(map[uint]bool)
+	for _, f := range folders {
+		if !match(f) {
+			continue
+		}
+		matched[f.ID] = true
+		for _, id := range parsePathIDs(f.Path) {
+			keep[id] = true
+		}
+		keep[f.ID] = true
+	}
+
+	kept := make([]*model.Folder, 0, len(keep))
+	for _, f := range folders {
+		if keep[f.ID] {
+			kept = append(kept, f)
+		}
+	}
+
+	return buildTreeWith(kept, nil, func(f *model.Folder, node *model.FolderNode) {
+		node.Matched = matched[f.ID]
+	}), nil
+}
+
+// treeMatcher 根据过滤条件生成匹配函数
+func (s *Service) treeMatcher(filter *TreeFilter) func(folder *model.Folder) bool {
+	if filter != nil && filter.Predicate != nil {
+		return filter.Predicate
+	}
+
+	query := ""
+	mode := MatchSubstring
+	if filter != nil {
+		query = strings.ToLower(strings.TrimSpace(filter.Query))
+		if filter.Match != "" {
+			mode = filter.Match
+		}
+	}
+	if query == "" {
+		return func(*model.Folder) bool { return true }
+	}
+
+	matches := func(value, keyword string) bool {
+		if mode == MatchPrefix {
+			return strings.HasPrefix(value, keyword)
+		}
+		return strings.Contains(value, keyword)
+	}
+	spelled := strings.Join(strings.Fields(query), "")
+	return func(f *model.Folder) bool {
+		if matches(strings.ToLower(f.Name), query) {
+			return true
+		}
+		return s.config.PinyinIndex && spelled != "" &&
+			(matches(f.Pinyin, spelled) || matches(f.PinyinInitials, spelled))
+	}
+}
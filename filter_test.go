@@ -0,0 +1,119 @@
+package folder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/KOMKZ/go-yogan-domain-folder/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetFilteredTree_KeepsAncestors 测试保留命中节点的祖先链
+func TestGetFilteredTree_KeepsAncestors(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	tech := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Golang", &tech.ID)
+	mustCreateFolder(t, svc, "并发", &golang.ID)
+	mustCreateFolder(t, svc, "Rust", &tech.ID)
+	life := mustCreateFolder(t, svc, "生活", nil)
+	mustCreateFolder(t, svc, "Go 旅行", &life.ID)
+	mustCreateFolder(t, svc, "读书", nil)
+
+	tree, err := svc.GetFilteredTree(ctx, &TreeFilter{Query: "go"})
+	require.NoError(t, err)
+
+	require.Len(t, tree, 2)
+	assert.Equal(t, "技术", tree[0].Name)
+	assert.False(t, tree[0].Matched)
+	require.Len(t, tree[0].Children, 1)
+	assert.Equal(t, "Golang", tree[0].Children[0].Name)
+	assert.True(t, tree[0].Children[0].Matched)
+	// 命中节点的子节点不保留
+	assert.Empty(t, tree[0].Children[0].Children)
+
+	assert.Equal(t, "生活", tree[1].Name)
+	assert.False(t, tree[1].Matched)
+	require.Len(t, tree[1].Children, 1)
+	assert.True(t, tree[1].Children[0].Matched)
+}
+
+// TestGetFilteredTree_Predicate 测试自定义匹配条件
+func TestGetFilteredTree_Predicate(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	tech := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &tech.ID)
+	mustCreateFolder(t, svc, "并发", &golang.ID)
+
+	tree, err := svc.GetFilteredTree(ctx, &TreeFilter{
+		Query:     "ignored",
+		Predicate: func(f *model.Folder) bool { return f.Depth == 2 },
+	})
+	require.NoError(t, err)
+	require.Len(t, tree, 1)
+	leaf := tree[0].Children[0].Children[0]
+	assert.Equal(t, "并发", leaf.Name)
+	assert.True(t, leaf.Matched)
+	assert.False(t, tree[0].Children[0].Matched)
+}
+
+// TestGetFilteredTree_Prefix 测试前缀匹配，祖先同时命中时标记为命中
+func TestGetFilteredTree_Prefix(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	goRoot := mustCreateFolder(t, svc, "Go", nil)
+	mustCreateFolder(t, svc, "Go 泛型", &goRoot.ID)
+	mustCreateFolder(t, svc, "Django", &goRoot.ID)
+
+	tree, err := svc.GetFilteredTree(ctx, &TreeFilter{Query: "GO", Match: MatchPrefix})
+	require.NoError(t, err)
+	require.Len(t, tree, 1)
+	assert.True(t, tree[0].Matched)
+	require.Len(t, tree[0].Children, 1)
+	assert.Equal(t, "Go 泛型", tree[0].Children[0].Name)
+}
+
+// TestGetFilteredTree_EmptyFilter 测试空条件返回完整的树
+func TestGetFilteredTree_EmptyFilter(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	root := mustCreateFolder(t, svc, "技术", nil)
+	mustCreateFolder(t, svc, "Go", &root.ID)
+
+	tree, err := svc.GetFilteredTree(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, tree, 1)
+	assert.True(t, tree[0].Matched)
+	assert.Len(t, tree[0].Children, 1)
+
+	tree, err = svc.GetFilteredTree(ctx, &TreeFilter{Query: "不存在"})
+	require.NoError(t, err)
+	assert.Empty(t, tree)
+}
+
+// TestGetFilteredTree_Pinyin 测试启用拼音索引时按拼音过滤
+func TestGetFilteredTree_Pinyin(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewServiceWithConfig(repo, ServiceConfig{PinyinIndex: true})
+	ctx := context.Background()
+
+	root := mustCreateFolder(t, svc, "资料", nil)
+	mustCreateFolder(t, svc, "技术文章", &root.ID)
+
+	tree, err := svc.GetFilteredTree(ctx, &TreeFilter{Query: "jswz"})
+	require.NoError(t, err)
+	require.Len(t, tree, 1)
+	assert.False(t, tree[0].Matched)
+	assert.Equal(t, "技术文章", tree[0].Children[0].Name)
+	assert.True(t, tree[0].Children[0].Matched)
+}
@@ -0,0 +1,357 @@
+package folder
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/KOMKZ/go-yogan-domain-folder/model"
+	"gopkg.in/yaml.v3"
+)
+
+// ImportFormat 导入格式
+type ImportFormat string
+
+const (
+	ImportFormatJSON ImportFormat = "json" // 嵌套 JSON：[{"name": "...", "children": [...]}]
+	ImportFormatYAML ImportFormat = "yaml" // 嵌套 YAML，结构同 JSON
+	ImportFormatCSV  ImportFormat = "csv"  // 扁平 CSV，每行一个名称路径，如 "Electronics/Phones/Android"
+)
+
+// ImportNode 嵌套导入节点
+type ImportNode struct {
+	Name     string        `json:"name" yaml:"name"`
+	Children []*ImportNode `json:"children,omitempty" yaml:"children,omitempty"`
+}
+
+// ImportOptions 导入选项
+type ImportOptions struct {
+	Format        ImportFormat
+	PathColumn    string // CSV 路径列名，默认 "path"
+	Separator     string // CSV 路径分隔符，默认 "/"，名称中的分隔符用反斜杠转义
+	MergeExisting bool   // 复用同级已存在的同名文件夹，否则视为重名错误
+	DryRun        bool   // 只校验，不写入
+}
+
+// ImportError 单行导入错误
+type ImportError struct {
+	Row     int    `json:"row"`     // CSV 为文件行号，嵌套格式为先序遍历序号（均从 1 开始）
+	Path    string `json:"path"`    // 名称路径
+	Message string `json:"message"` // 错误信息
+	Err     error  `json:"-"`
+}
+
+// ImportReport 导入报告
+type ImportReport struct {
+	Created int            `json:"created"` // 新建的节点数
+	Reused  int            `json:"reused"`  // 复用的已有节点数
+	Errors  []*ImportError `json:"errors"`
+}
+
+// importItem 待导入的一项，segments 为从导入根开始的名称路径
+type importItem struct {
+	row      int
+	segments []string
+}
+
+// ImportTree 从 r 读取指定格式的树并在 parentID 下创建
+// 所有节点在同一事务中创建；任一行校验失败时整体回滚，返回 ErrImportInvalid 和逐行错误报告
+func (s *Service) ImportTree(ctx context.Context, parentID *uint, r io.Reader, opts *ImportOptions) (*ImportReport, error) {
+	if opts == nil {
+		opts = &ImportOptions{}
+	}
+
+	var items []importItem
+	var err error
+	switch opts.Format {
+	case ImportFormatJSON, ImportFormatYAML:
+		var nodes []*ImportNode
+		nodes, err = decodeImportNodes(r, opts.Format)
+		if err == nil {
+			items = flattenImportNodes(nodes)
+		}
+	case ImportFormatCSV:
+		items, err = readImportCSV(r, opts)
+	default:
+		err = fmt.Errorf("folder: unsupported import format %q", opts.Format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.importItems(ctx, parentID, items, opts)
+}
+
+// ImportNodes 在 parentID 下导入已解析的嵌套节点
+func (s *Service) ImportNodes(ctx context.Context, parentID *uint, nodes []*ImportNode, opts *ImportOptions) (*ImportReport, error) {
+	if opts == nil {
+		opts = &ImportOptions{}
+	}
+	return s.importItems(ctx, parentID, flattenImportNodes(nodes), opts)
+}
+
+// errDryRun 用于回滚试运行事务
+var errDryRun = errors.New("folder: import dry run")
+
+// importItems 在一个事务中导入所有项
+func (s *Service) importItems(ctx context.Context, parentID *uint, items []importItem, opts *ImportOptions) (*ImportReport, error) {
+	separator := opts.Separator
+	if separator == "" {
+		separator = "/"
+	}
+
+	var report *ImportReport
+	err := s.repo.WithTx(ctx, func(repo Repository) error {
+		if parentID != nil {
+			if _, err := repo.FindByID(ctx, *parentID); err != nil {
+				return ErrParentNotFound
+			}
+		}
+
+		im := &importer{
+			svc:       s,
+			repo:      repo,
+			opts:      opts,
+			separator: separator,
+			report:    &ImportReport{Errors: []*ImportError{}},
+			resolved:  make(map[string]*uint),
+			failed:    make(map[string]bool),
+			existing:  make(map[string]map[string]*model.Folder),
+		}
+		for _, item := range items {
+			if err := im.importItem(ctx, parentID, item); err != nil {
+				return err
+			}
+		}
+		report = im.report
+
+		if len(report.Errors) > 0 {
+			return ErrImportInvalid
+		}
+		if opts.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if errors.Is(err, errDryRun) {
+		return report, nil
+	}
+	if err != nil {
+		if errors.Is(err, ErrImportInvalid) {
+			return report, err
+		}
+		return nil, err
+	}
+	return report, nil
+}
+
+// importer 单次导入的状态
+type importer struct {
+	svc       *Service
+	repo      Repository
+	opts      *ImportOptions
+	separator string
+	report    *ImportReport
+	resolved  map[string]*uint                    // 名称路径 -> 已创建或复用的文件夹 ID
+	failed    map[string]bool                     // 导入失败的名称路径
+	existing  map[string]map[string]*model.Folder // 父节点 -> 名称 -> 已有子节点（MergeExisting 时使用）
+}
+
+// importItem 导入一项，自动补齐路径中缺失的上级节点
+func (im *importer) importItem(ctx context.Context, rootID *uint, item importItem) error {
+	fail := func(path string, err error) {
+		im.report.Errors = append(im.report.Errors, &ImportError{
+			Row:     item.row,
+			Path:    path,
+			Message: err.Error(),
+			Err:     err,
+		})
+	}
+
+	if len(item.segments) == 0 {
+		fail("", ErrInvalidName)
+		return nil
+	}
+
+	parentID := rootID
+	for i, name := range item.segments {
+		path := JoinNamePath(item.segments[:i+1], im.separator)
+		last := i == len(item.segments)-1
+
+		if im.failed[path] {
+			// 上级导入失败，或同一失败路径重复出现
+			if last {
+				fail(path, ErrDuplicateName)
+			} else {
+				fail(JoinNamePath(item.segments, im.separator), ErrParentNotFound)
+			}
+			return nil
+		}
+		if id, ok := im.resolved[path]; ok {
+			if last {
+				// 同一路径重复出现
+				if !im.opts.MergeExisting {
+					fail(path, ErrDuplicateName)
+				}
+				return nil
+			}
+			parentID = id
+			continue
+		}
+
+		if im.opts.MergeExisting {
+			existing, err := im.findExisting(ctx, parentID, name)
+			if err != nil {
+				return err
+			}
+			if existing != nil {
+				id := existing.ID
+				im.resolved[path] = &id
+				im.report.Reused++
+				parentID = &id
+				continue
+			}
+		}
+
+		created, err := im.svc.createFolder(ctx, im.repo, &CreateFolderInput{Name: name, ParentID: parentID})
+		if err != nil {
+			if isImportValidationError(err) {
+				im.failed[path] = true
+				fail(path, err)
+				return nil
+			}
+			return err
+		}
+		id := created.ID
+		im.resolved[path] = &id
+		im.report.Created++
+		parentID = &id
+	}
+	return nil
+}
+
+// findExisting 查找父节点下的同名子节点
+func (im *importer) findExisting(ctx context.Context, parentID *uint, name string) (*model.Folder, error) {
+	key := "root"
+	if parentID != nil {
+		key = fmt.Sprintf("%d", *parentID)
+	}
+	children, ok := im.existing[key]
+	if !ok {
+		folders, err := im.repo.FindByParentID(ctx, parentID)
+		if err != nil {
+			return nil, err
+		}
+		children = make(map[string]*model.Folder, len(folders))
+		for _, f := range folders {
+			children[f.Name] = f
+		}
+		im.existing[key] = children
+	}
+	return children[name], nil
+}
+
+// isImportValidationError 判断是否为可逐行报告的校验错误
+func isImportValidationError(err error) bool {
+	return errors.Is(err, ErrInvalidName) ||
+		errors.Is(err, ErrDuplicateName) ||
+		errors.Is(err, ErrMaxDepthExceeded)
+}
+
+// decodeImportNodes 解析嵌套 JSON/YAML，顶层可以是节点数组或单个节点
+func decodeImportNodes(r io.Reader, format ImportFormat) ([]*ImportNode, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	unmarshal := json.Unmarshal
+	if format == ImportFormatYAML {
+		unmarshal = yaml.Unmarshal
+	}
+
+	var nodes []*ImportNode
+	if err := unmarshal(data, &nodes); err == nil {
+		return nodes, nil
+	}
+	var node ImportNode
+	if err := unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	return []*ImportNode{&node}, nil
+}
+
+// flattenImportNodes 先序遍历嵌套节点
+func flattenImportNodes(nodes []*ImportNode) []importItem {
+	var items []importItem
+	var walk func(nodes []*ImportNode, prefix []string)
+	walk = func(nodes []*ImportNode, prefix []string) {
+		for _, n := range nodes {
+			segments := append(append([]string{}, prefix...), strings.TrimSpace(n.Name))
+			items = append(items, importItem{row: len(items) + 1, segments: segments})
+			walk(n.Children, segments)
+		}
+	}
+	walk(nodes, nil)
+	return items
+}
+
+// readImportCSV 读取扁平 CSV，首行为表头
+func readImportCSV(r io.Reader, opts *ImportOptions) ([]importItem, error) {
+	column := opts.PathColumn
+	if column == "" {
+		column = "path"
+	}
+	separator := opts.Separator
+	if separator == "" {
+		separator = "/"
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	index := -1
+	for i, h := range header {
+		if strings.EqualFold(strings.TrimSpace(h), column) {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("folder: csv column %q not found", column)
+	}
+
+	var items []importItem
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// 空行会被跳过、带引号的字段可跨行，行号取自字段在文件中的起始位置
+		var line int
+		var segments []string
+		if index < len(record) {
+			line, _ = reader.FieldPos(index)
+			for _, seg := range SplitNamePath(record[index], separator) {
+				if seg = strings.TrimSpace(seg); seg != "" {
+					segments = append(segments, seg)
+				}
+			}
+		} else {
+			line, _ = reader.FieldPos(0)
+		}
+		items = append(items, importItem{row: line, segments: segments})
+	}
+	return items, nil
+}
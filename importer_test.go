@@ -0,0 +1,198 @@
+package folder
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/KOMKZ/go-yogan-domain-folder/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// findChild 按名称查找父节点下的子节点
+func findChild(t *testing.T, repo Repository, parentID *uint, name string) *model.Folder {
+	t.Helper()
+	children, err := repo.FindByParentID(context.Background(), parentID)
+	require.NoError(t, err)
+	for _, c := range children {
+		if c.Name == name {
+			return c
+		}
+	}
+	t.Fatalf("folder %q not found", name)
+	return nil
+}
+
+// TestImportTree_JSON 测试导入嵌套 JSON
+func TestImportTree_JSON(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	root := mustCreateFolder(t, svc, "导入", nil)
+	data := `[
+		{"name": "技术", "children": [
+			{"name": "Go", "children": [{"name": "并发"}]},
+			{"name": "Rust"}
+		]},
+		{"name": "生活"}
+	]`
+
+	report, err := svc.ImportTree(ctx, &root.ID, strings.NewReader(data), &ImportOptions{Format: ImportFormatJSON})
+	require.NoError(t, err)
+	assert.Equal(t, 5, report.Created)
+	assert.Empty(t, report.Errors)
+
+	assert.Equal(t, []string{"技术", "生活"}, siblingNames(t, repo, &root.ID))
+	tech := findChild(t, repo, &root.ID, "技术")
+	assert.Equal(t, 1, tech.Depth)
+	assert.Equal(t, []string{"Go", "Rust"}, siblingNames(t, repo, &tech.ID))
+
+	golang := findChild(t, repo, &tech.ID, "Go")
+	assert.Equal(t, []string{"并发"}, siblingNames(t, repo, &golang.ID))
+}
+
+// TestImportTree_YAMLSingleNode 测试导入单个 YAML 节点
+func TestImportTree_YAMLSingleNode(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	data := "name: 技术\nchildren:\n  - name: Go\n  - name: Rust\n"
+
+	report, err := svc.ImportTree(ctx, nil, strings.NewReader(data), &ImportOptions{Format: ImportFormatYAML})
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Created)
+
+	assert.Equal(t, []string{"技术"}, siblingNames(t, repo, nil))
+	tech := findChild(t, repo, nil, "技术")
+	assert.Equal(t, []string{"Go", "Rust"}, siblingNames(t, repo, &tech.ID))
+}
+
+// TestImportTree_CSV 测试导入扁平 CSV 并自动补齐上级
+func TestImportTree_CSV(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	data := "path,note\nElectronics/Phones/Android,a\nElectronics/Phones/iOS,b\nBooks,c\n"
+
+	report, err := svc.ImportTree(ctx, nil, strings.NewReader(data), &ImportOptions{Format: ImportFormatCSV})
+	require.NoError(t, err)
+	assert.Equal(t, 5, report.Created)
+
+	assert.Equal(t, []string{"Electronics", "Books"}, siblingNames(t, repo, nil))
+	electronics := findChild(t, repo, nil, "Electronics")
+	phones := findChild(t, repo, &electronics.ID, "Phones")
+	assert.Equal(t, 1, phones.Depth)
+	assert.Equal(t, []string{"Android", "iOS"}, siblingNames(t, repo, &phones.ID))
+}
+
+// TestImportTree_RowErrorsRollBack 测试校验失败时整体回滚并报告每一行
+func TestImportTree_RowErrorsRollBack(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewServiceWithConfig(repo, ServiceConfig{MaxDepth: 2})
+	ctx := context.Background()
+
+	mustCreateFolder(t, svc, "Books", nil)
+	data := "path\nElectronics/Phones\nBooks\nA/B/C\n" + strings.Repeat("x", 300) + "\n"
+
+	report, err := svc.ImportTree(ctx, nil, strings.NewReader(data), &ImportOptions{Format: ImportFormatCSV})
+	require.ErrorIs(t, err, ErrImportInvalid)
+	require.Len(t, report.Errors, 3)
+
+	assert.Equal(t, 3, report.Errors[0].Row)
+	assert.Equal(t, "Books", report.Errors[0].Path)
+	assert.ErrorIs(t, report.Errors[0].Err, ErrDuplicateName)
+
+	assert.Equal(t, 4, report.Errors[1].Row)
+	assert.Equal(t, "A/B/C", report.Errors[1].Path)
+	assert.ErrorIs(t, report.Errors[1].Err, ErrMaxDepthExceeded)
+
+	assert.Equal(t, 5, report.Errors[2].Row)
+	assert.ErrorIs(t, report.Errors[2].Err, ErrInvalidName)
+
+	// 已创建的节点全部回滚
+	assert.Equal(t, []string{"Books"}, siblingNames(t, repo, nil))
+}
+
+// TestImportTree_CSVRowIsFileLine 测试空行和跨行字段不影响报告的文件行号
+func TestImportTree_CSVRowIsFileLine(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	data := "path\nA\n\nB\n\"C\nD\"\nA\n"
+	report, err := svc.ImportTree(ctx, nil, strings.NewReader(data), &ImportOptions{Format: ImportFormatCSV})
+	require.ErrorIs(t, err, ErrImportInvalid)
+	require.Len(t, report.Errors, 1)
+	assert.Equal(t, 7, report.Errors[0].Row)
+	assert.Equal(t, "A", report.Errors[0].Path)
+	assert.ErrorIs(t, report.Errors[0].Err, ErrDuplicateName)
+}
+
+// TestImportTree_FailedParentReportsChildren 测试父节点失败时子节点同样报错
+func TestImportTree_FailedParentReportsChildren(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	nodes := []*ImportNode{
+		{Name: "", Children: []*ImportNode{{Name: "Go"}}},
+	}
+
+	report, err := svc.ImportNodes(ctx, nil, nodes, nil)
+	require.ErrorIs(t, err, ErrImportInvalid)
+	require.Len(t, report.Errors, 2)
+	assert.ErrorIs(t, report.Errors[0].Err, ErrInvalidName)
+	assert.Equal(t, 2, report.Errors[1].Row)
+	assert.ErrorIs(t, report.Errors[1].Err, ErrParentNotFound)
+}
+
+// TestImportTree_MergeExisting 测试复用已有文件夹
+func TestImportTree_MergeExisting(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	electronics := mustCreateFolder(t, svc, "Electronics", nil)
+	mustCreateFolder(t, svc, "Phones", &electronics.ID)
+	data := "path\nElectronics/Phones/Android\nElectronics/Laptops\n"
+
+	report, err := svc.ImportTree(ctx, nil, strings.NewReader(data), &ImportOptions{
+		Format:        ImportFormatCSV,
+		MergeExisting: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Created)
+	assert.Equal(t, 2, report.Reused)
+	assert.Equal(t, []string{"Phones", "Laptops"}, siblingNames(t, repo, &electronics.ID))
+}
+
+// TestImportTree_DryRun 测试试运行不写入
+func TestImportTree_DryRun(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	data := `{"name": "技术", "children": [{"name": "Go"}]}`
+
+	report, err := svc.ImportTree(ctx, nil, strings.NewReader(data), &ImportOptions{
+		Format: ImportFormatJSON,
+		DryRun: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Created)
+	assert.Empty(t, siblingNames(t, repo, nil))
+}
+
+// TestImportTree_ParentNotFound 测试目标父节点不存在
+func TestImportTree_ParentNotFound(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+
+	missing := uint(999)
+	_, err := svc.ImportTree(context.Background(), &missing, strings.NewReader("path\nA\n"), &ImportOptions{Format: ImportFormatCSV})
+	assert.ErrorIs(t, err, ErrParentNotFound)
+}
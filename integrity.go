@@ -0,0 +1,357 @@
+package folder
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/KOMKZ/go-yogan-domain-folder/model"
+)
+
+// IntegrityIssueType 完整性问题类型
+type IntegrityIssueType string
+
+const (
+	IssueOrphan        IntegrityIssueType = "orphan"         // 父节点不存在或已删除
+	IssueCycle         IntegrityIssueType = "cycle"          // parent_id 形成环
+	IssueWrongDepth    IntegrityIssueType = "wrong_depth"    // depth 与父链不一致
+	IssueWrongPath     IntegrityIssueType = "wrong_path"     // path 与父链不一致
+	IssueDuplicateName IntegrityIssueType = "duplicate_name" // 同级名称重复
+)
+
+// IntegrityIssue 完整性问题
+type IntegrityIssue struct {
+	Type     IntegrityIssueType `json:"type"`
+	FolderID uint               `json:"folderId"`
+	Expected string             `json:"expected,omitempty"`
+	Actual   string             `json:"actual,omitempty"`
+	Message  string             `json:"message"`
+}
+
+// IntegrityReport 完整性检查报告
+type IntegrityReport struct {
+	Checked int               `json:"checked"` // 检查的节点数
+	Issues  []*IntegrityIssue `json:"issues"`
+}
+
+// OK 是否没有发现问题
+func (r *IntegrityReport) OK() bool {
+	return len(r.Issues) == 0
+}
+
+// RepairOptions 修复选项
+type RepairOptions struct {
+	DryRun        bool // 只生成修复报告，不写入
+	DetachOrphans bool // 将孤儿节点及环上的一个节点移到根节点；否则跳过它们所在的子树
+}
+
+// RepairChange 一项修复
+type RepairChange struct {
+	FolderID    uint   `json:"folderId"`
+	OldParentID *uint  `json:"oldParentId"`
+	NewParentID *uint  `json:"newParentId"`
+	OldPath     string `json:"oldPath"`
+	NewPath     string `json:"newPath"`
+	OldDepth    int    `json:"oldDepth"`
+	NewDepth    int    `json:"newDepth"`
+	OldName     string `json:"oldName,omitempty"` // 移到根节点时因重名而改名
+	NewName     string `json:"newName,omitempty"`
+}
+
+// RepairReport 修复报告
+type RepairReport struct {
+	DryRun  bool            `json:"dryRun"`
+	Changes []*RepairChange `json:"changes"`
+	Skipped []uint          `json:"skipped"` // 因孤儿或环无法确定位置而跳过的节点
+}
+
+// treeAnalysis 根据 parent_id 分析出的树结构
+type treeAnalysis struct {
+	byID     map[uint]*model.Folder
+	children map[uint][]*model.Folder // 父 ID -> 子节点，0 表示根
+	orphans  []*model.Folder          // 父节点不在有效记录中
+	cycles   [][]*model.Folder        // 每个环上的节点，按 ID 排序
+	expected map[uint]expectedPosition
+}
+
+// expectedPosition 由父链推导出的路径与深度
+type expectedPosition struct {
+	path  string
+	depth int
+}
+
+// analyzeTree 分析 parent_id 关系，推导从根可达节点的期望路径与深度
+func analyzeTree(folders []*model.Folder) *treeAnalysis {
+	a := &treeAnalysis{
+		byID:     make(map[uint]*model.Folder, len(folders)),
+		children: make(map[uint][]*model.Folder),
+		expected: make(map[uint]expectedPosition, len(folders)),
+	}
+	for _, f := range folders {
+		a.byID[f.ID] = f
+	}
+	for _, f := range folders {
+		parent := uint(0)
+		if f.ParentID != nil {
+			parent = *f.ParentID
+			if _, ok := a.byID[parent]; !ok {
+				a.orphans = append(a.orphans, f)
+			}
+		}
+		a.children[parent] = append(a.children[parent], f)
+	}
+
+	// 从根节点向下推导
+	var walk func(parentID uint, parentPath string, depth int)
+	walk = func(parentID uint, parentPath string, depth int) {
+		for _, child := range a.children[parentID] {
+			path := parentPath + strconv.FormatUint(uint64(child.ID), 10) + "/"
+			a.expected[child.ID] = expectedPosition{path: path, depth: depth}
+			walk(child.ID, path, depth+1)
+		}
+	}
+	walk(0, "/", 0)
+
+	// 既不可从根到达、也不在孤儿子树中的节点必然处于环上或环的下游
+	orphanSubtree := make(map[uint]bool)
+	var mark func(id uint)
+	mark = func(id uint) {
+		orphanSubtree[id] = true
+		for _, child := range a.children[id] {
+			if !orphanSubtree[child.ID] {
+				mark(child.ID)
+			}
+		}
+	}
+	for _, o := range a.orphans {
+		mark(o.ID)
+	}
+
+	state := make(map[uint]int) // 0 未访问，1 访问中，2 已完成
+	for _, f := range folders {
+		if _, ok := a.expected[f.ID]; ok || orphanSubtree[f.ID] || state[f.ID] != 0 {
+			continue
+		}
+		// 沿父链向上，直到遇到已访问的节点
+		var chain []*model.Folder
+		current := f
+		for current != nil && state[current.ID] == 0 {
+			state[current.ID] = 1
+			chain = append(chain, current)
+			current = a.byID[*current.ParentID]
+		}
+		if current != nil && state[current.ID] == 1 {
+			// 回到本次链上的节点，截取环
+			var cycle []*model.Folder
+			for i := len(chain) - 1; i >= 0; i-- {
+				cycle = append(cycle, chain[i])
+				if chain[i].ID == current.ID {
+					break
+				}
+			}
+			sort.Slice(cycle, func(i, j int) bool { return cycle[i].ID < cycle[j].ID })
+			a.cycles = append(a.cycles, cycle)
+		}
+		for _, c := range chain {
+			state[c.ID] = 2
+		}
+	}
+	return a
+}
+
+// CheckIntegrity 检查 parent_id、path、depth 是否一致，以及同级名称是否重复
+func (s *Service) CheckIntegrity(ctx context.Context) (*IntegrityReport, error) {
+	folders, err := s.repo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	a := analyzeTree(folders)
+	report := &IntegrityReport{Checked: len(folders), Issues: []*IntegrityIssue{}}
+
+	// 区分父节点已删除与不存在
+	var missingParents []uint
+	for _, o := range a.orphans {
+		missingParents = append(missingParents, *o.ParentID)
+	}
+	deletedParents, err := s.repo.FindByIDsUnscoped(ctx, missingParents)
+	if err != nil {
+		return nil, err
+	}
+	deleted := make(map[uint]bool, len(deletedParents))
+	for _, p := range deletedParents {
+		deleted[p.ID] = true
+	}
+	for _, o := range a.orphans {
+		message := fmt.Sprintf("父节点 %d 不存在", *o.ParentID)
+		if deleted[*o.ParentID] {
+			message = fmt.Sprintf("父节点 %d 已删除", *o.ParentID)
+		}
+		report.Issues = append(report.Issues, &IntegrityIssue{
+			Type:     IssueOrphan,
+			FolderID: o.ID,
+			Message:  message,
+		})
+	}
+
+	for _, cycle := range a.cycles {
+		ids := make([]string, 0, len(cycle))
+		for _, c := range cycle {
+			ids = append(ids, strconv.FormatUint(uint64(c.ID), 10))
+		}
+		for _, c := range cycle {
+			report.Issues = append(report.Issues, &IntegrityIssue{
+				Type:     IssueCycle,
+				FolderID: c.ID,
+				Message:  fmt.Sprintf("parent_id 形成环：%v", ids),
+			})
+		}
+	}
+
+	for _, f := range folders {
+		expected, ok := a.expected[f.ID]
+		if !ok {
+			continue
+		}
+		if f.Depth != expected.depth {
+			report.Issues = append(report.Issues, &IntegrityIssue{
+				Type:     IssueWrongDepth,
+				FolderID: f.ID,
+				Expected: strconv.Itoa(expected.depth),
+				Actual:   strconv.Itoa(f.Depth),
+				Message:  "depth 与父链不一致",
+			})
+		}
+		if f.Path != expected.path {
+			report.Issues = append(report.Issues, &IntegrityIssue{
+				Type:     IssueWrongPath,
+				FolderID: f.ID,
+				Expected: expected.path,
+				Actual:   f.Path,
+				Message:  "path 与父链不一致",
+			})
+		}
+	}
+
+	for _, siblings := range a.children {
+		seen := make(map[string]uint)
+		for _, f := range siblings {
+			if first, ok := seen[f.Name]; ok {
+				report.Issues = append(report.Issues, &IntegrityIssue{
+					Type:     IssueDuplicateName,
+					FolderID: f.ID,
+					Actual:   f.Name,
+					Message:  fmt.Sprintf("与同级节点 %d 重名", first),
+				})
+				continue
+			}
+			seen[f.Name] = f.ID
+		}
+	}
+
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		return report.Issues[i].FolderID < report.Issues[j].FolderID
+	})
+	return report, nil
+}
+
+// Repair 根据 parent_id 重建 path 与 depth
+// DetachOrphans 为 true 时先将孤儿节点与环上 ID 最小的节点移到根节点（重名时改名为 "name (n)"）
+// 同级重名只在 CheckIntegrity 中报告，不自动修复
+func (s *Service) Repair(ctx context.Context, opts *RepairOptions) (*RepairReport, error) {
+	if opts == nil {
+		opts = &RepairOptions{}
+	}
+
+	report := &RepairReport{DryRun: opts.DryRun, Changes: []*RepairChange{}, Skipped: []uint{}}
+	err := s.repo.WithTx(ctx, func(repo Repository) error {
+		folders, err := repo.FindAll(ctx)
+		if err != nil {
+			return err
+		}
+		a := analyzeTree(folders)
+		originals := make(map[uint]model.Folder, len(folders))
+		for _, f := range folders {
+			originals[f.ID] = *f
+		}
+
+		// 需要移到根节点的节点
+		detached := make(map[uint]bool)
+		if opts.DetachOrphans {
+			for _, o := range a.orphans {
+				detached[o.ID] = true
+			}
+			for _, cycle := range a.cycles {
+				detached[cycle[0].ID] = true
+			}
+			for _, f := range folders {
+				if detached[f.ID] {
+					f.ParentID = nil
+				}
+			}
+			a = analyzeTree(folders)
+		}
+
+		// 按深度从浅到深写入
+		sort.SliceStable(folders, func(i, j int) bool {
+			return a.expected[folders[i].ID].depth < a.expected[folders[j].ID].depth
+		})
+
+		for _, f := range folders {
+			expected, ok := a.expected[f.ID]
+			if !ok {
+				report.Skipped = append(report.Skipped, f.ID)
+				continue
+			}
+			if !detached[f.ID] && f.Path == expected.path && f.Depth == expected.depth {
+				continue
+			}
+
+			original := originals[f.ID]
+			change := &RepairChange{
+				FolderID:    f.ID,
+				OldParentID: original.ParentID,
+				NewParentID: f.ParentID,
+				OldPath:     original.Path,
+				NewPath:     expected.path,
+				OldDepth:    original.Depth,
+				NewDepth:    expected.depth,
+			}
+			f.Path = expected.path
+			f.Depth = expected.depth
+
+			if detached[f.ID] {
+				name, err := uniqueName(ctx, repo, f.Name, nil, &f.ID)
+				if err != nil {
+					return err
+				}
+				if name != f.Name {
+					change.OldName, change.NewName = f.Name, name
+					f.Name = name
+					s.indexPinyin(f)
+				}
+				if !opts.DryRun {
+					if err := appendOrder(ctx, repo, s.config, f, nil); err != nil {
+						return err
+					}
+					if err := repo.Update(ctx, f); err != nil {
+						return err
+					}
+				}
+			} else if !opts.DryRun {
+				if err := repo.UpdatePathAndDepth(ctx, f.ID, f.Path, f.Depth); err != nil {
+					return err
+				}
+			}
+			report.Changes = append(report.Changes, change)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(report.Changes, func(i, j int) bool { return report.Changes[i].FolderID < report.Changes[j].FolderID })
+	sort.Slice(report.Skipped, func(i, j int) bool { return report.Skipped[i] < report.Skipped[j] })
+	return report, nil
+}
@@ -0,0 +1,182 @@
+package folder
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// corrupt 直接修改数据库中的字段，模拟手工 SQL 或失败的操作
+func corrupt(t *testing.T, db *gorm.DB, id uint, column string, value interface{}) {
+	t.Helper()
+	require.NoError(t, db.Table(testTableName).Where("id = ?", id).Update(column, value).Error)
+}
+
+// issuesOf 按类型收集问题节点
+func issuesOf(report *IntegrityReport, typ IntegrityIssueType) []uint {
+	var ids []uint
+	for _, issue := range report.Issues {
+		if issue.Type == typ {
+			ids = append(ids, issue.FolderID)
+		}
+	}
+	return ids
+}
+
+// TestCheckIntegrity_Healthy 测试正常的树没有问题
+func TestCheckIntegrity_Healthy(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	tech := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &tech.ID)
+	mustCreateFolder(t, svc, "并发", &golang.ID)
+
+	report, err := svc.CheckIntegrity(ctx)
+	require.NoError(t, err)
+	assert.True(t, report.OK())
+	assert.Equal(t, 3, report.Checked)
+}
+
+// TestCheckIntegrity_DetectsIssues 测试检测各类问题
+func TestCheckIntegrity_DetectsIssues(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewGormRepository(db, testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	tech := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &tech.ID)
+	rust := mustCreateFolder(t, svc, "Rust", &tech.ID)
+	life := mustCreateFolder(t, svc, "生活", nil)
+	travel := mustCreateFolder(t, svc, "旅行", &life.ID)
+	a := mustCreateFolder(t, svc, "A", nil)
+	b := mustCreateFolder(t, svc, "B", &a.ID)
+	trashed := mustCreateFolder(t, svc, "回收", nil)
+	lost := mustCreateFolder(t, svc, "遗留", &trashed.ID)
+
+	corrupt(t, db, golang.ID, "depth", 5)
+	corrupt(t, db, rust.ID, "path", "/999/")
+	corrupt(t, db, travel.ID, "parent_id", 12345)
+	corrupt(t, db, a.ID, "parent_id", b.ID)
+	corrupt(t, db, rust.ID, "name", "Go")
+	require.NoError(t, db.Table(testTableName).Where("id = ?", trashed.ID).Update("deleted_at", gorm.Expr("CURRENT_TIMESTAMP")).Error)
+
+	report, err := svc.CheckIntegrity(ctx)
+	require.NoError(t, err)
+	assert.False(t, report.OK())
+
+	assert.Equal(t, []uint{golang.ID}, issuesOf(report, IssueWrongDepth))
+	assert.Equal(t, []uint{rust.ID}, issuesOf(report, IssueWrongPath))
+	assert.ElementsMatch(t, []uint{travel.ID, lost.ID}, issuesOf(report, IssueOrphan))
+	assert.Equal(t, []uint{a.ID, b.ID}, issuesOf(report, IssueCycle))
+	assert.Len(t, issuesOf(report, IssueDuplicateName), 1)
+
+	for _, issue := range report.Issues {
+		switch {
+		case issue.FolderID == travel.ID:
+			assert.Contains(t, issue.Message, "不存在")
+		case issue.FolderID == lost.ID:
+			assert.Contains(t, issue.Message, "已删除")
+		case issue.Type == IssueWrongPath:
+			assert.Equal(t, fmt.Sprintf("/%d/%d/", tech.ID, rust.ID), issue.Expected)
+			assert.Equal(t, "/999/", issue.Actual)
+		}
+	}
+}
+
+// TestRepair_RebuildsPathAndDepth 测试根据 parent_id 重建 path 与 depth
+func TestRepair_RebuildsPathAndDepth(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewGormRepository(db, testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	tech := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &tech.ID)
+	concurrency := mustCreateFolder(t, svc, "并发", &golang.ID)
+	other := mustCreateFolder(t, svc, "其他", nil)
+
+	// 模拟移动中途失败：parent_id 已更新，path 与 depth 未更新
+	corrupt(t, db, golang.ID, "parent_id", other.ID)
+
+	// 试运行只报告不写入
+	report, err := svc.Repair(ctx, &RepairOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.True(t, report.DryRun)
+	require.Len(t, report.Changes, 2)
+	assert.Equal(t, fmt.Sprintf("/%d/%d/", other.ID, golang.ID), report.Changes[0].NewPath)
+	stored, err := repo.FindByID(ctx, concurrency.ID)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("/%d/%d/%d/", tech.ID, golang.ID, concurrency.ID), stored.Path)
+
+	report, err = svc.Repair(ctx, nil)
+	require.NoError(t, err)
+	assert.Len(t, report.Changes, 2)
+
+	stored, err = repo.FindByID(ctx, concurrency.ID)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("/%d/%d/%d/", other.ID, golang.ID, concurrency.ID), stored.Path)
+	assert.Equal(t, 2, stored.Depth)
+
+	check, err := svc.CheckIntegrity(ctx)
+	require.NoError(t, err)
+	assert.True(t, check.OK())
+}
+
+// TestRepair_Orphans 测试孤儿与环的处理
+func TestRepair_Orphans(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewGormRepository(db, testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	life := mustCreateFolder(t, svc, "生活", nil)
+	travel := mustCreateFolder(t, svc, "旅行", &life.ID)
+	photos := mustCreateFolder(t, svc, "照片", &travel.ID)
+	mustCreateFolder(t, svc, "旅行", nil)
+	a := mustCreateFolder(t, svc, "A", nil)
+	b := mustCreateFolder(t, svc, "B", &a.ID)
+
+	corrupt(t, db, travel.ID, "parent_id", 12345)
+	corrupt(t, db, a.ID, "parent_id", b.ID)
+
+	// 默认跳过孤儿与环
+	report, err := svc.Repair(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, report.Changes)
+	assert.Equal(t, []uint{travel.ID, photos.ID, a.ID, b.ID}, report.Skipped)
+
+	// 移到根节点，重名时改名
+	report, err = svc.Repair(ctx, &RepairOptions{DetachOrphans: true})
+	require.NoError(t, err)
+	assert.Empty(t, report.Skipped)
+
+	stored, err := repo.FindByID(ctx, travel.ID)
+	require.NoError(t, err)
+	assert.Nil(t, stored.ParentID)
+	assert.Equal(t, "旅行 (1)", stored.Name)
+	assert.Equal(t, 0, stored.Depth)
+
+	stored, err = repo.FindByID(ctx, photos.ID)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("/%d/%d/", travel.ID, photos.ID), stored.Path)
+	assert.Equal(t, 1, stored.Depth)
+
+	// 环上 ID 最小的节点移到根节点
+	stored, err = repo.FindByID(ctx, a.ID)
+	require.NoError(t, err)
+	assert.Nil(t, stored.ParentID)
+	stored, err = repo.FindByID(ctx, b.ID)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("/%d/%d/", a.ID, b.ID), stored.Path)
+
+	check, err := svc.CheckIntegrity(ctx)
+	require.NoError(t, err)
+	assert.True(t, check.OK(), "%+v", check.Issues)
+}
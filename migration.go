@@ -0,0 +1,90 @@
+package folder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// UniqueNameIndexName 同级名称唯一索引名
+func (r *GormRepository) UniqueNameIndexName() string {
+	return fmt.Sprintf("uk_%s_sibling_name", r.tableName)
+}
+
+// UniqueNameIndexDDL 返回当前数据库方言下同级名称唯一索引的 DDL
+// 索引覆盖 (作用域, 父节点, 名称)，只约束未删除的记录：
+//   - parent_id 为 NULL 的根节点以 COALESCE(parent_id, 0) 参与比较，避免 NULL 互不相等
+//   - PostgreSQL/SQLite 使用部分索引 WHERE deleted_at IS NULL
+//   - MySQL 不支持部分索引，已删除记录的 IF(deleted_at IS NULL, 1, NULL) 为 NULL，不参与唯一比较（需 8.0.13+）
+//     不引用 id：MySQL 函数索引不允许使用自增列
+func (r *GormRepository) UniqueNameIndexDDL() (string, error) {
+	quote := func(name string) string {
+		var b strings.Builder
+		r.db.Dialector.QuoteTo(&b, name)
+		return b.String()
+	}
+
+	var columns []string
+	if r.scopeColumn != "" {
+		columns = append(columns, quote(r.scopeColumn))
+	}
+
+	switch r.db.Dialector.Name() {
+	case "mysql":
+		columns = append(columns, "(COALESCE(parent_id, 0))", "name", "(IF(deleted_at IS NULL, 1, NULL))")
+		return fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s (%s)",
+			quote(r.UniqueNameIndexName()), quote(r.tableName), strings.Join(columns, ", ")), nil
+	case "postgres", "sqlite":
+		columns = append(columns, "COALESCE(parent_id, 0)", "name")
+		return fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s (%s) WHERE deleted_at IS NULL",
+			quote(r.UniqueNameIndexName()), quote(r.tableName), strings.Join(columns, ", ")), nil
+	default:
+		return "", fmt.Errorf("folder: unique name index not supported for dialect %q", r.db.Dialector.Name())
+	}
+}
+
+// MigrateUniqueNameIndex 创建同级名称唯一索引，已存在时跳过
+// 创建前需确保表中没有重名的同级记录
+func (r *GormRepository) MigrateUniqueNameIndex(ctx context.Context) error {
+	db := r.db.WithContext(ctx)
+	if db.Migrator().HasIndex(r.tableName, r.UniqueNameIndexName()) {
+		return nil
+	}
+	ddl, err := r.UniqueNameIndexDDL()
+	if err != nil {
+		return err
+	}
+	return db.Exec(ddl).Error
+}
+
+// translateError 将唯一约束冲突转换为 ErrDuplicateName
+func translateError(err error) error {
+	if isUniqueViolation(err) {
+		return ErrDuplicateName
+	}
+	return err
+}
+
+// isUniqueViolation 判断是否为唯一约束冲突，兼容 MySQL、PostgreSQL 和 SQLite 驱动
+func isUniqueViolation(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+
+	// PostgreSQL 驱动（pgconn.PgError）提供 SQLState
+	var state interface{ SQLState() string }
+	if errors.As(err, &state) && state.SQLState() == "23505" {
+		return true
+	}
+
+	msg := err.Error()
+	return strings.Contains(msg, "Error 1062") || // MySQL ER_DUP_ENTRY
+		strings.Contains(msg, "SQLSTATE 23505") || // PostgreSQL
+		strings.Contains(msg, "UNIQUE constraint failed") // SQLite
+}
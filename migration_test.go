@@ -0,0 +1,148 @@
+package folder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/KOMKZ/go-yogan-domain-folder/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// TestUniqueNameIndexDDL_SQLite 测试 SQLite 方言下的 DDL
+func TestUniqueNameIndexDDL_SQLite(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName, WithScopeColumn("tenant_id"))
+
+	ddl, err := repo.UniqueNameIndexDDL()
+	require.NoError(t, err)
+	assert.Equal(t,
+		"CREATE UNIQUE INDEX `uk_test_folders_sibling_name` ON `test_folders` "+
+			"(`tenant_id`, COALESCE(parent_id, 0), name) WHERE deleted_at IS NULL",
+		ddl)
+}
+
+// TestUniqueNameIndexDDL_MySQL 测试 MySQL 方言下的 DDL：不引用自增列 id
+func TestUniqueNameIndexDDL_MySQL(t *testing.T) {
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "folder:folder@tcp(127.0.0.1:3306)/folder",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	require.NoError(t, err)
+	repo := NewGormRepository(db, testTableName, WithScopeColumn("tenant_id"))
+
+	ddl, err := repo.UniqueNameIndexDDL()
+	require.NoError(t, err)
+	assert.Equal(t,
+		"CREATE UNIQUE INDEX `uk_test_folders_sibling_name` ON `test_folders` "+
+			"(`tenant_id`, (COALESCE(parent_id, 0)), name, (IF(deleted_at IS NULL, 1, NULL)))",
+		ddl)
+}
+
+// TestMigrateUniqueNameIndex_Idempotent 测试重复迁移
+func TestMigrateUniqueNameIndex_Idempotent(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	ctx := context.Background()
+
+	require.NoError(t, repo.MigrateUniqueNameIndex(ctx))
+	require.NoError(t, repo.MigrateUniqueNameIndex(ctx))
+}
+
+// TestUniqueNameIndex_RejectsDuplicates 测试绕过应用层检查时由数据库拒绝重名
+func TestUniqueNameIndex_RejectsDuplicates(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	ctx := context.Background()
+	require.NoError(t, repo.MigrateUniqueNameIndex(ctx))
+
+	// 根节点（parent_id 为 NULL）
+	require.NoError(t, repo.Create(ctx, &model.Folder{Name: "技术", Path: "/"}))
+	err := repo.Create(ctx, &model.Folder{Name: "技术", Path: "/"})
+	assert.ErrorIs(t, err, ErrDuplicateName)
+
+	// 子节点
+	parentID := uint(1)
+	require.NoError(t, repo.Create(ctx, &model.Folder{Name: "Go", ParentID: &parentID, Path: "/1/"}))
+	err = repo.Create(ctx, &model.Folder{Name: "Go", ParentID: &parentID, Path: "/1/"})
+	assert.ErrorIs(t, err, ErrDuplicateName)
+
+	// 不同父节点允许同名
+	otherID := uint(2)
+	assert.NoError(t, repo.Create(ctx, &model.Folder{Name: "Go", ParentID: &otherID, Path: "/2/"}))
+}
+
+// TestUniqueNameIndex_IgnoresDeleted 测试已删除记录不参与唯一约束
+func TestUniqueNameIndex_IgnoresDeleted(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+	require.NoError(t, repo.MigrateUniqueNameIndex(ctx))
+
+	old := mustCreateFolder(t, svc, "技术", nil)
+	require.NoError(t, svc.DeleteFolder(ctx, old.ID))
+	mustCreateFolder(t, svc, "技术", nil)
+
+	// 恢复时自动改名，不触发唯一约束
+	result, err := svc.RestoreFolder(ctx, old.ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "技术 (1)", result.Folder.Name)
+}
+
+// TestUniqueNameIndex_Scoped 测试作用域内唯一
+func TestUniqueNameIndex_Scoped(t *testing.T) {
+	repo := NewGormRepository(newScopedTestDB(t), testTableName, WithScopeColumn("tenant_id"))
+	require.NoError(t, repo.MigrateUniqueNameIndex(context.Background()))
+	ctxA := ContextWithScope(context.Background(), "a")
+	ctxB := ContextWithScope(context.Background(), "b")
+
+	require.NoError(t, repo.Create(ctxA, &model.Folder{Name: "技术", Path: "/"}))
+	require.NoError(t, repo.Create(ctxB, &model.Folder{Name: "技术", Path: "/"}))
+	err := repo.Create(ctxA, &model.Folder{Name: "技术", Path: "/"})
+	assert.ErrorIs(t, err, ErrDuplicateName)
+}
+
+// TestUniqueNameIndex_MoveIntoConflict 测试移动到存在同名节点的父节点下
+func TestUniqueNameIndex_MoveIntoConflict(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+	require.NoError(t, repo.MigrateUniqueNameIndex(ctx))
+
+	a := mustCreateFolder(t, svc, "A", nil)
+	mustCreateFolder(t, svc, "Go", &a.ID)
+	golang := mustCreateFolder(t, svc, "Go", nil)
+
+	err := svc.MoveFolder(ctx, golang.ID, &a.ID)
+	assert.ErrorIs(t, err, ErrDuplicateName)
+}
+
+// pgError 模拟 pgconn.PgError
+type pgError struct{ code string }
+
+func (e *pgError) Error() string    { return "pg error" }
+func (e *pgError) SQLState() string { return e.code }
+
+// TestIsUniqueViolation 测试唯一约束冲突识别
+func TestIsUniqueViolation(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"mysql", errors.New("Error 1062 (23000): Duplicate entry 'x' for key 'uk'"), true},
+		{"postgres_state", fmt.Errorf("wrapped: %w", &pgError{code: "23505"}), true},
+		{"postgres_other_state", &pgError{code: "23503"}, false},
+		{"postgres_message", errors.New(`ERROR: duplicate key value violates unique constraint "uk" (SQLSTATE 23505)`), true},
+		{"sqlite", errors.New("UNIQUE constraint failed: index 'uk'"), true},
+		{"other", errors.New("connection refused"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isUniqueViolation(tt.err))
+		})
+	}
+}
@@ -9,15 +9,19 @@ import (
 // Folder 通用文件夹/层级节点模型
 // 注意：不实现 TableName() 方法，表名由 Repository 动态指定
 type Folder struct {
-	ID        uint           `gorm:"primaryKey" json:"id"`
-	Name      string         `gorm:"size:255;not null" json:"name"`
-	ParentID  *uint          `gorm:"index" json:"parentId"`
-	SortOrder int            `gorm:"default:0" json:"sortOrder"`
-	Depth     int            `gorm:"default:0" json:"depth"`
-	Path      string         `gorm:"size:1000" json:"path"` // 物化路径，如 "/1/3/5/"
-	CreatedAt time.Time      `json:"createdAt"`
-	UpdatedAt time.Time      `json:"updatedAt"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"deletedAt,omitempty"`
+	ID             uint           `gorm:"primaryKey" json:"id"`
+	Name           string         `gorm:"size:255;not null" json:"name"`
+	ParentID       *uint          `gorm:"index" json:"parentId"`
+	SortOrder      int            `gorm:"default:0" json:"sortOrder"`
+	SortKey        string         `gorm:"size:255;not null;default:''" json:"sortKey,omitempty"`        // 排序键模式下的字典序排序键
+	Pinyin         string         `gorm:"size:1000;not null;default:''" json:"pinyin,omitempty"`        // 名称全拼，启用拼音索引时维护
+	PinyinInitials string         `gorm:"size:255;not null;default:''" json:"pinyinInitials,omitempty"` // 名称拼音首字母
+	Depth          int            `gorm:"default:0" json:"depth"`
+	Path           string         `gorm:"size:1000" json:"path"`             // 物化路径，如 "/1/3/5/"
+	Version        uint           `gorm:"not null;default:0" json:"version"` // 乐观锁版本号，每次更新递增
+	CreatedAt      time.Time      `json:"createdAt"`
+	UpdatedAt      time.Time      `json:"updatedAt"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"deletedAt,omitempty"`
 
 	// 非数据库字段
 	Children []Folder `gorm:"-" json:"children,omitempty"`
@@ -25,12 +29,16 @@ type Folder struct {
 
 // FolderNode 用于树形结构展示
 type FolderNode struct {
-	ID        uint          `json:"id"`
-	Name      string        `json:"name"`
-	ParentID  *uint         `json:"parentId"`
-	SortOrder int           `json:"sortOrder"`
-	Depth     int           `json:"depth"`
-	Children  []*FolderNode `json:"children,omitempty"`
+	ID              uint          `json:"id"`
+	Name            string        `json:"name"`
+	ParentID        *uint         `json:"parentId"`
+	SortOrder       int           `json:"sortOrder"`
+	Depth           int           `json:"depth"`
+	Matched         bool          `json:"matched,omitempty"` // 过滤树中表示自身命中，false 表示仅作为命中节点的祖先保留
+	HasChildren     bool          `json:"hasChildren"`       // 是否有子节点（包括未加载的）
+	ChildCount      int           `json:"childCount"`        // 直接子节点数量（包括未加载的）
+	DescendantCount int           `json:"descendantCount"`   // 子孙节点总数（由 GetTree / GetSubTree 填充）
+	Children        []*FolderNode `json:"children,omitempty"`
 }
 
 // ToNode 将 Folder 转换为 FolderNode
@@ -44,3 +52,19 @@ func (f *Folder) ToNode() *FolderNode {
 		Children:  nil,
 	}
 }
+
+// FolderClosure 闭包表记录：Ancestor 到 Descendant 的距离，节点到自身的距离为 0
+// 注意：不实现 TableName() 方法，表名由 Repository 动态指定
+type FolderClosure struct {
+	Ancestor   uint `gorm:"primaryKey;autoIncrement:false" json:"ancestor"`
+	Descendant uint `gorm:"primaryKey;autoIncrement:false;index" json:"descendant"`
+	Distance   int  `gorm:"not null" json:"distance"`
+}
+
+// FolderNestedSet 嵌套集合区间：子孙节点的区间包含在祖先节点的区间内
+// 注意：不实现 TableName() 方法，表名由 Repository 动态指定
+type FolderNestedSet struct {
+	FolderID uint `gorm:"primaryKey;autoIncrement:false" json:"folderId"`
+	Lft      int  `gorm:"not null;index" json:"lft"`
+	Rgt      int  `gorm:"not null;index" json:"rgt"`
+}
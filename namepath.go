@@ -0,0 +1,103 @@
+package folder
+
+import (
+	"context"
+	"strings"
+
+	"github.com/KOMKZ/go-yogan-domain-folder/model"
+)
+
+// defaultNamePathSeparator 默认名称路径分隔符
+const defaultNamePathSeparator = "/"
+
+// namePathEscape 名称路径转义符，"\/" 表示名称中的分隔符，"\\" 表示反斜杠本身
+const namePathEscape = '\\'
+
+// FindByNamePath 按名称路径查找文件夹，如 "技术/Go/并发"
+// 名称中包含分隔符时需用反斜杠转义，如 "C\/C++"
+func (s *Service) FindByNamePath(ctx context.Context, namePath string) (*model.Folder, error) {
+	names := SplitNamePath(namePath, s.namePathSeparator())
+	if len(names) == 0 {
+		return nil, ErrNotFound
+	}
+	return s.repo.FindByNamePath(ctx, names)
+}
+
+// GetNamePath 获取文件夹的名称路径，结果可直接用于 FindByNamePath
+func (s *Service) GetNamePath(ctx context.Context, id uint) (string, error) {
+	folder, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	ancestors, err := s.repo.FindAncestors(ctx, folder.Path)
+	if err != nil {
+		return "", err
+	}
+
+	names := make([]string, 0, len(ancestors)+1)
+	for _, a := range ancestors {
+		if a.ID != folder.ID {
+			names = append(names, a.Name)
+		}
+	}
+	names = append(names, folder.Name)
+	return JoinNamePath(names, s.namePathSeparator()), nil
+}
+
+// namePathSeparator 名称路径分隔符
+func (s *Service) namePathSeparator() string {
+	if s.config.NamePathSeparator == "" {
+		return defaultNamePathSeparator
+	}
+	return s.config.NamePathSeparator
+}
+
+// SplitNamePath 按分隔符拆分名称路径并处理转义，忽略空段
+func SplitNamePath(namePath, separator string) []string {
+	if separator == "" {
+		separator = defaultNamePathSeparator
+	}
+
+	var names []string
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			names = append(names, current.String())
+			current.Reset()
+		}
+	}
+	for i := 0; i < len(namePath); {
+		switch {
+		case namePath[i] == namePathEscape && i+1 < len(namePath):
+			// 转义分隔符或下一个字符
+			if strings.HasPrefix(namePath[i+1:], separator) {
+				current.WriteString(separator)
+				i += 1 + len(separator)
+			} else {
+				current.WriteByte(namePath[i+1])
+				i += 2
+			}
+		case strings.HasPrefix(namePath[i:], separator):
+			flush()
+			i += len(separator)
+		default:
+			current.WriteByte(namePath[i])
+			i++
+		}
+	}
+	flush()
+	return names
+}
+
+// JoinNamePath 拼接名称路径，名称中的分隔符和反斜杠会被转义
+func JoinNamePath(names []string, separator string) string {
+	if separator == "" {
+		separator = defaultNamePathSeparator
+	}
+	escaped := make([]string, len(names))
+	for i, name := range names {
+		name = strings.ReplaceAll(name, string(namePathEscape), string(namePathEscape)+string(namePathEscape))
+		escaped[i] = strings.ReplaceAll(name, separator, string(namePathEscape)+separator)
+	}
+	return strings.Join(escaped, separator)
+}
@@ -0,0 +1,120 @@
+package folder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSplitNamePath 测试名称路径拆分
+func TestSplitNamePath(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		separator string
+		expected  []string
+	}{
+		{"empty", "", "/", nil},
+		{"single", "技术", "/", []string{"技术"}},
+		{"multiple", "技术/Go/并发", "/", []string{"技术", "Go", "并发"}},
+		{"leading_trailing", "/技术/Go/", "/", []string{"技术", "Go"}},
+		{"escaped_separator", `编程/C\/C++`, "/", []string{"编程", "C/C++"}},
+		{"escaped_backslash", `a\\/b`, "/", []string{`a\`, "b"}},
+		{"custom_separator", "技术 > Go > 并发", " > ", []string{"技术", "Go", "并发"}},
+		{"custom_escaped", `a\ > b > c`, " > ", []string{"a > b", "c"}},
+		{"default_separator", "a/b", "", []string{"a", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, SplitNamePath(tt.path, tt.separator))
+		})
+	}
+}
+
+// TestJoinNamePath 测试名称路径拼接与拆分互逆
+func TestJoinNamePath(t *testing.T) {
+	names := []string{"编程", "C/C++", `a\b`}
+	joined := JoinNamePath(names, "/")
+	assert.Equal(t, `编程/C\/C++/a\\b`, joined)
+	assert.Equal(t, names, SplitNamePath(joined, "/"))
+}
+
+// TestFindByNamePath 测试按名称路径查找
+func TestFindByNamePath(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	tech := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &tech.ID)
+	concurrency := mustCreateFolder(t, svc, "并发", &golang.ID)
+	// 其他分支下的同名节点不应被匹配
+	life := mustCreateFolder(t, svc, "生活", nil)
+	otherGo := mustCreateFolder(t, svc, "Go", &life.ID)
+	mustCreateFolder(t, svc, "并发", &otherGo.ID)
+	cpp := mustCreateFolder(t, svc, "C/C++", &tech.ID)
+
+	found, err := svc.FindByNamePath(ctx, "技术/Go/并发")
+	require.NoError(t, err)
+	assert.Equal(t, concurrency.ID, found.ID)
+
+	found, err = svc.FindByNamePath(ctx, "/技术/")
+	require.NoError(t, err)
+	assert.Equal(t, tech.ID, found.ID)
+
+	found, err = svc.FindByNamePath(ctx, `技术/C\/C++`)
+	require.NoError(t, err)
+	assert.Equal(t, cpp.ID, found.ID)
+
+	_, err = svc.FindByNamePath(ctx, "技术/Rust")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = svc.FindByNamePath(ctx, "Go/并发")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = svc.FindByNamePath(ctx, "")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	namePath, err := svc.GetNamePath(ctx, cpp.ID)
+	require.NoError(t, err)
+	assert.Equal(t, `技术/C\/C++`, namePath)
+}
+
+// TestFindByNamePath_CustomSeparator 测试自定义分隔符
+func TestFindByNamePath_CustomSeparator(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewServiceWithConfig(repo, ServiceConfig{NamePathSeparator: "::"})
+	ctx := context.Background()
+
+	tech := mustCreateFolder(t, svc, "技术", nil)
+	web := mustCreateFolder(t, svc, "Web/API", &tech.ID)
+
+	found, err := svc.FindByNamePath(ctx, "技术::Web/API")
+	require.NoError(t, err)
+	assert.Equal(t, web.ID, found.ID)
+
+	namePath, err := svc.GetNamePath(ctx, web.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "技术::Web/API", namePath)
+}
+
+// TestFindByNamePath_Scoped 测试作用域隔离
+func TestFindByNamePath_Scoped(t *testing.T) {
+	repo := NewGormRepository(newScopedTestDB(t), testTableName, WithScopeColumn("tenant_id"))
+	svc := NewService(repo)
+	ctxA := ContextWithScope(context.Background(), "a")
+	ctxB := ContextWithScope(context.Background(), "b")
+
+	tech, err := svc.CreateFolder(ctxA, &CreateFolderInput{Name: "技术"})
+	require.NoError(t, err)
+	golang, err := svc.CreateFolder(ctxA, &CreateFolderInput{Name: "Go", ParentID: &tech.ID})
+	require.NoError(t, err)
+
+	found, err := svc.FindByNamePath(ctxA, "技术/Go")
+	require.NoError(t, err)
+	assert.Equal(t, golang.ID, found.ID)
+
+	_, err = svc.FindByNamePath(ctxB, "技术/Go")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
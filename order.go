@@ -0,0 +1,367 @@
+package folder
+
+import (
+	"context"
+
+	"github.com/KOMKZ/go-yogan-domain-folder/model"
+)
+
+// PlaceFolderInput 拖拽定位输入
+// BeforeID 与 AfterID 至多指定一个，都为空时放到末尾
+type PlaceFolderInput struct {
+	ID       uint
+	ParentID *uint // 目标父节点，nil 表示根节点
+	BeforeID *uint // 放到该兄弟节点之前
+	AfterID  *uint // 放到该兄弟节点之后
+	Version  *uint // 期望版本号，非空时校验
+}
+
+// PlaceFolder 将文件夹移动到目标父节点下的指定位置
+// 父节点变化时按 MoveFolder 的规则校验并更新子树，随后调整目标父节点下的兄弟顺序：
+// 整数排序号模式下重排为 1..n，排序键模式下只改写被移动的节点
+func (s *Service) PlaceFolder(ctx context.Context, input *PlaceFolderInput) error {
+	if input.BeforeID != nil && input.AfterID != nil {
+		return ErrInvalidAnchor
+	}
+	anchorID := input.BeforeID
+	if anchorID == nil {
+		anchorID = input.AfterID
+	}
+	if anchorID != nil && *anchorID == input.ID {
+		return ErrInvalidAnchor
+	}
+
+	return s.repo.WithTx(ctx, func(repo Repository) error {
+		folder, err := repo.FindByID(ctx, input.ID)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(folder, input.Version); err != nil {
+			return err
+		}
+
+		// 期望版本号由首次写入校验：父节点变化时为移动写入，否则为排序值写入
+		version := input.Version
+		if !sameParent(folder.ParentID, input.ParentID) {
+			if err := s.moveFolder(ctx, repo, input.ID, input.ParentID, input.Version); err != nil {
+				return err
+			}
+			version = nil
+		}
+
+		siblings, self, err := loadSiblings(ctx, repo, input.ParentID, input.ID)
+		if err != nil {
+			return err
+		}
+
+		pos := len(siblings)
+		if anchorID != nil {
+			pos = -1
+			for i, sibling := range siblings {
+				if sibling.ID == *anchorID {
+					pos = i
+					break
+				}
+			}
+			if pos < 0 {
+				return ErrInvalidAnchor
+			}
+			if input.AfterID != nil {
+				pos++
+			}
+		}
+
+		return s.applySiblingOrder(ctx, repo, insertAt(siblings, self, pos), pos, version)
+	})
+}
+
+// moveToPosition 将文件夹移动到同级中的第 position 个位置（从 1 开始，越界时取首尾）
+func (s *Service) moveToPosition(ctx context.Context, repo Repository, folder *model.Folder, position int, version *uint) error {
+	siblings, self, err := loadSiblings(ctx, repo, folder.ParentID, folder.ID)
+	if err != nil {
+		return err
+	}
+
+	pos := position - 1
+	if pos < 0 {
+		pos = 0
+	}
+	if pos > len(siblings) {
+		pos = len(siblings)
+	}
+	return s.applySiblingOrder(ctx, repo, insertAt(siblings, self, pos), pos, version)
+}
+
+// applySiblingOrder 按 ordered 的顺序持久化兄弟节点顺序，pos 为被移动节点所在位置
+// version 非空时被移动节点先以该版本号写入目标排序值，行已被修改时返回 ErrVersionConflict
+func (s *Service) applySiblingOrder(ctx context.Context, repo Repository, ordered []*model.Folder, pos int, version *uint) error {
+	self := ordered[pos]
+	if s.config.OrderMode != OrderModeSortKey {
+		if err := updateOrderVersioned(ctx, repo, self, pos+1, self.SortKey, version); err != nil {
+			return err
+		}
+		return renumberSiblings(ctx, repo, ordered)
+	}
+
+	rebalance := func() error {
+		if err := updateOrderVersioned(ctx, repo, self, 0, evenSortKeys(len(ordered))[pos], version); err != nil {
+			return err
+		}
+		return rebalanceSortKeys(ctx, repo, ordered)
+	}
+
+	// 相邻节点缺少排序键（如由整数排序号模式迁移而来）时整体重排
+	var prev, next string
+	if pos > 0 {
+		prev = ordered[pos-1].SortKey
+		if prev == "" || ordered[pos-1].SortOrder != 0 {
+			return rebalance()
+		}
+	}
+	if pos < len(ordered)-1 {
+		next = ordered[pos+1].SortKey
+		if next == "" || next <= prev || ordered[pos+1].SortOrder != 0 {
+			return rebalance()
+		}
+	}
+
+	key := sortKeyBetween(prev, next)
+	if len(key) > s.maxSortKeyLength() {
+		return rebalance()
+	}
+
+	if err := updateOrderVersioned(ctx, repo, self, 0, key, version); err != nil {
+		return err
+	}
+	if self.SortOrder != 0 {
+		if err := repo.UpdateSortOrder(ctx, self.ID, 0); err != nil {
+			return err
+		}
+		self.SortOrder = 0
+	}
+	if self.SortKey != key {
+		if err := repo.UpdateSortKey(ctx, self.ID, key); err != nil {
+			return err
+		}
+		self.SortKey = key
+	}
+	return nil
+}
+
+// updateOrderVersioned 以期望版本号 version 写入 folder 的排序号与排序键，version 为空时不写入
+// 版本校验与写入在同一条语句中完成；写入后后续按值比较的批量更新会跳过该节点
+func updateOrderVersioned(ctx context.Context, repo Repository, folder *model.Folder, sortOrder int, sortKey string, version *uint) error {
+	if version == nil {
+		return nil
+	}
+	folder.SortOrder = sortOrder
+	folder.SortKey = sortKey
+	folder.Version = *version
+	return repo.Update(ctx, folder)
+}
+
+// NormalizeSortOrder 将父节点下兄弟节点的排序号按当前显示顺序重写为 1..n
+// 用于修复重复、间断或负数的排序号
+func (s *Service) NormalizeSortOrder(ctx context.Context, parentID *uint) error {
+	return s.repo.WithTx(ctx, func(repo Repository) error {
+		siblings, err := repo.FindByParentID(ctx, parentID)
+		if err != nil {
+			return err
+		}
+		return renumberSiblings(ctx, repo, siblings)
+	})
+}
+
+// NormalizeAllSortOrders 对整棵树的每一组兄弟节点执行 NormalizeSortOrder
+func (s *Service) NormalizeAllSortOrders(ctx context.Context) error {
+	return s.repo.WithTx(ctx, func(repo Repository) error {
+		folders, err := repo.FindAll(ctx)
+		if err != nil {
+			return err
+		}
+		for _, group := range groupByParent(folders) {
+			if err := renumberSiblings(ctx, repo, group); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RebalanceSortKeys 将父节点下兄弟节点的排序键按当前顺序重写为均匀分布的短键
+// 排序键过长时会自动触发；由整数排序号模式切换到排序键模式时也可用于初始化
+func (s *Service) RebalanceSortKeys(ctx context.Context, parentID *uint) error {
+	return s.repo.WithTx(ctx, func(repo Repository) error {
+		siblings, err := repo.FindByParentID(ctx, parentID)
+		if err != nil {
+			return err
+		}
+		return rebalanceSortKeys(ctx, repo, siblings)
+	})
+}
+
+// RebalanceAllSortKeys 对整棵树的每一组兄弟节点执行 RebalanceSortKeys
+func (s *Service) RebalanceAllSortKeys(ctx context.Context) error {
+	return s.repo.WithTx(ctx, func(repo Repository) error {
+		folders, err := repo.FindAll(ctx)
+		if err != nil {
+			return err
+		}
+		for _, group := range groupByParent(folders) {
+			if err := rebalanceSortKeys(ctx, repo, group); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// maxSortKeyLength 返回排序键最大长度
+func (s *Service) maxSortKeyLength() int {
+	if s.config.MaxSortKeyLength > 0 {
+		return s.config.MaxSortKeyLength
+	}
+	return defaultMaxSortKeyLength
+}
+
+// appendOrder 为 folder 分配 parentID 下末尾位置的排序值（不写库）
+func appendOrder(ctx context.Context, repo Repository, config ServiceConfig, folder *model.Folder, parentID *uint) error {
+	if config.OrderMode != OrderModeSortKey {
+		maxOrder, err := repo.FindMaxSortOrder(ctx, parentID)
+		if err != nil {
+			return err
+		}
+		folder.SortOrder = maxOrder + 1
+		return nil
+	}
+
+	maxKey, err := repo.FindMaxSortKey(ctx, parentID)
+	if err != nil {
+		return err
+	}
+	key := sortKeyBetween(maxKey, "")
+
+	maxLength := config.MaxSortKeyLength
+	if maxLength <= 0 {
+		maxLength = defaultMaxSortKeyLength
+	}
+	if len(key) > maxLength {
+		// 先重排已有兄弟节点（不含自身，避免自身版本号变化）
+		siblings, err := repo.FindByParentID(ctx, parentID)
+		if err != nil {
+			return err
+		}
+		others := make([]*model.Folder, 0, len(siblings))
+		for _, sibling := range siblings {
+			if sibling.ID != folder.ID {
+				others = append(others, sibling)
+			}
+		}
+		if err := rebalanceSortKeys(ctx, repo, others); err != nil {
+			return err
+		}
+		key = sortKeyBetween("", "")
+		if len(others) > 0 {
+			key = sortKeyBetween(others[len(others)-1].SortKey, "")
+		}
+	}
+
+	folder.SortOrder = 0
+	folder.SortKey = key
+	return nil
+}
+
+// rebalanceSortKeys 按给定顺序为兄弟节点重写均匀分布的排序键，并清零整数排序号
+func rebalanceSortKeys(ctx context.Context, repo Repository, ordered []*model.Folder) error {
+	keys := evenSortKeys(len(ordered))
+	for i, sibling := range ordered {
+		if sibling.SortOrder != 0 {
+			if err := repo.UpdateSortOrder(ctx, sibling.ID, 0); err != nil {
+				return err
+			}
+			sibling.SortOrder = 0
+		}
+		if sibling.SortKey == keys[i] {
+			continue
+		}
+		if err := repo.UpdateSortKey(ctx, sibling.ID, keys[i]); err != nil {
+			return err
+		}
+		sibling.SortKey = keys[i]
+	}
+	return nil
+}
+
+// renumberSiblings 按给定顺序将兄弟节点的排序号重写为 1..n，仅更新发生变化的节点
+func renumberSiblings(ctx context.Context, repo Repository, ordered []*model.Folder) error {
+	for i, sibling := range ordered {
+		order := i + 1
+		if sibling.SortOrder == order {
+			continue
+		}
+		if err := repo.UpdateSortOrder(ctx, sibling.ID, order); err != nil {
+			return err
+		}
+		sibling.SortOrder = order
+	}
+	return nil
+}
+
+// loadSiblings 查询父节点下的兄弟节点，返回不含 id 的有序列表及 id 对应的节点
+func loadSiblings(ctx context.Context, repo Repository, parentID *uint, id uint) ([]*model.Folder, *model.Folder, error) {
+	siblings, err := repo.FindByParentID(ctx, parentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	others := make([]*model.Folder, 0, len(siblings))
+	var self *model.Folder
+	for _, sibling := range siblings {
+		if sibling.ID == id {
+			self = sibling
+			continue
+		}
+		others = append(others, sibling)
+	}
+	if self == nil {
+		return nil, nil, ErrNotFound
+	}
+	return others, self, nil
+}
+
+// insertAt 将 folder 插入到 list 的 pos 位置
+func insertAt(list []*model.Folder, folder *model.Folder, pos int) []*model.Folder {
+	return append(list[:pos], append([]*model.Folder{folder}, list[pos:]...)...)
+}
+
+// groupByParent 按父节点分组，组内保持原有顺序
+func groupByParent(folders []*model.Folder) [][]*model.Folder {
+	index := make(map[uint]int)
+	var roots []*model.Folder
+	var groups [][]*model.Folder
+	for _, f := range folders {
+		if f.ParentID == nil {
+			roots = append(roots, f)
+			continue
+		}
+		i, ok := index[*f.ParentID]
+		if !ok {
+			i = len(groups)
+			index[*f.ParentID] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], f)
+	}
+	if len(roots) > 0 {
+		groups = append([][]*model.Folder{roots}, groups...)
+	}
+	return groups
+}
+
+// sameParent 判断两个父节点 ID 是否相同
+func sameParent(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,414 @@
+package folder
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/KOMKZ/go-yogan-domain-folder/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// siblingNames 返回父节点下按顺序排列的名称
+func siblingNames(t *testing.T, repo Repository, parentID *uint) []string {
+	t.Helper()
+	siblings, err := repo.FindByParentID(context.Background(), parentID)
+	require.NoError(t, err)
+	names := make([]string, 0, len(siblings))
+	for _, s := range siblings {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+// TestPlaceFolder_WithinParent 测试同级内拖拽
+func TestPlaceFolder_WithinParent(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	a := mustCreateFolder(t, svc, "A", nil)
+	b := mustCreateFolder(t, svc, "B", nil)
+	c := mustCreateFolder(t, svc, "C", nil)
+
+	require.NoError(t, svc.PlaceFolder(ctx, &PlaceFolderInput{ID: c.ID, BeforeID: &a.ID}))
+	assert.Equal(t, []string{"C", "A", "B"}, siblingNames(t, repo, nil))
+
+	require.NoError(t, svc.PlaceFolder(ctx, &PlaceFolderInput{ID: c.ID, AfterID: &b.ID}))
+	assert.Equal(t, []string{"A", "B", "C"}, siblingNames(t, repo, nil))
+
+	require.NoError(t, svc.PlaceFolder(ctx, &PlaceFolderInput{ID: a.ID}))
+	assert.Equal(t, []string{"B", "C", "A"}, siblingNames(t, repo, nil))
+
+	// 排序号保持稠密
+	siblings, err := repo.FindByParentID(ctx, nil)
+	require.NoError(t, err)
+	for i, s := range siblings {
+		assert.Equal(t, i+1, s.SortOrder)
+	}
+}
+
+// TestPlaceFolder_ToNewParent 测试跨父节点拖拽
+func TestPlaceFolder_ToNewParent(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	root := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &root.ID)
+	mustCreateFolder(t, svc, "Rust", &root.ID)
+	java := mustCreateFolder(t, svc, "Java", nil)
+	spring := mustCreateFolder(t, svc, "Spring", &java.ID)
+
+	require.NoError(t, svc.PlaceFolder(ctx, &PlaceFolderInput{ID: java.ID, ParentID: &root.ID, AfterID: &golang.ID}))
+	assert.Equal(t, []string{"Go", "Java", "Rust"}, siblingNames(t, repo, &root.ID))
+
+	moved, err := repo.FindByID(ctx, spring.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, moved.Depth)
+	assert.Equal(t, fmt.Sprintf("%s%d/%d/", root.Path, java.ID, spring.ID), moved.Path)
+}
+
+// TestPlaceFolder_AnchorNotSibling 测试锚点不在目标父节点下
+func TestPlaceFolder_AnchorNotSibling(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	root := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &root.ID)
+	other := mustCreateFolder(t, svc, "生活", nil)
+
+	err := svc.PlaceFolder(ctx, &PlaceFolderInput{ID: other.ID, BeforeID: &golang.ID})
+	assert.ErrorIs(t, err, ErrInvalidAnchor)
+
+	// 校验失败时不应发生移动
+	err = svc.PlaceFolder(ctx, &PlaceFolderInput{ID: other.ID, ParentID: &root.ID, BeforeID: &root.ID})
+	assert.ErrorIs(t, err, ErrInvalidAnchor)
+	assert.Equal(t, []string{"技术", "生活"}, siblingNames(t, repo, nil))
+}
+
+// TestPlaceFolder_InvalidInput 测试非法锚点参数
+func TestPlaceFolder_InvalidInput(t *testing.T) {
+	svc := NewService(new(MockRepository))
+	ctx := context.Background()
+	a, b := uint(1), uint(2)
+
+	err := svc.PlaceFolder(ctx, &PlaceFolderInput{ID: 3, BeforeID: &a, AfterID: &b})
+	assert.ErrorIs(t, err, ErrInvalidAnchor)
+
+	err = svc.PlaceFolder(ctx, &PlaceFolderInput{ID: 1, BeforeID: &a})
+	assert.ErrorIs(t, err, ErrInvalidAnchor)
+}
+
+// TestPlaceFolder_VersionConflict 测试期望版本号在写入时校验
+func TestPlaceFolder_VersionConflict(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	root := mustCreateFolder(t, svc, "技术", nil)
+	a := mustCreateFolder(t, svc, "A", nil)
+	b := mustCreateFolder(t, svc, "B", nil)
+
+	// 版本校验之后、写入之前行被修改
+	interleaved := &interleavedRepository{Repository: repo, on: "FindByParentID", change: func(repo Repository) {
+		require.NoError(t, repo.UpdateSortOrder(ctx, b.ID, 9))
+	}}
+	err := NewService(interleaved).PlaceFolder(ctx, &PlaceFolderInput{ID: b.ID, BeforeID: &a.ID, Version: &b.Version})
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	assert.Equal(t, []string{"技术", "A", "B"}, siblingNames(t, repo, nil))
+
+	stale := b.Version
+	require.NoError(t, svc.PlaceFolder(ctx, &PlaceFolderInput{ID: b.ID, BeforeID: &a.ID, Version: &b.Version}))
+	assert.Equal(t, []string{"技术", "B", "A"}, siblingNames(t, repo, nil))
+
+	// 跨父节点移动同样校验
+	err = svc.PlaceFolder(ctx, &PlaceFolderInput{ID: b.ID, ParentID: &root.ID, Version: &stale})
+	assert.ErrorIs(t, err, ErrVersionConflict)
+
+	current, err := repo.FindByID(ctx, b.ID)
+	require.NoError(t, err)
+	require.NoError(t, svc.PlaceFolder(ctx, &PlaceFolderInput{ID: b.ID, ParentID: &root.ID, Version: &current.Version}))
+	assert.Equal(t, []string{"B"}, siblingNames(t, repo, &root.ID))
+}
+
+// sortKeyConfig 排序键模式配置
+var sortKeyConfig = ServiceConfig{MaxDepth: 10, OrderMode: OrderModeSortKey}
+
+// TestSortKeyMode_Create 测试排序键模式下创建
+func TestSortKeyMode_Create(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewServiceWithConfig(repo, sortKeyConfig)
+
+	a := mustCreateFolder(t, svc, "A", nil)
+	b := mustCreateFolder(t, svc, "B", nil)
+	c := mustCreateFolder(t, svc, "C", nil)
+
+	assert.Equal(t, 0, a.SortOrder)
+	assert.Less(t, a.SortKey, b.SortKey)
+	assert.Less(t, b.SortKey, c.SortKey)
+	assert.Equal(t, []string{"A", "B", "C"}, siblingNames(t, repo, nil))
+}
+
+// TestSortKeyMode_PlaceTouchesOneRow 测试排序键模式下拖拽只改写被移动的节点
+func TestSortKeyMode_PlaceTouchesOneRow(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewServiceWithConfig(repo, sortKeyConfig)
+	ctx := context.Background()
+
+	a := mustCreateFolder(t, svc, "A", nil)
+	b := mustCreateFolder(t, svc, "B", nil)
+	c := mustCreateFolder(t, svc, "C", nil)
+
+	require.NoError(t, svc.PlaceFolder(ctx, &PlaceFolderInput{ID: c.ID, AfterID: &a.ID}))
+	assert.Equal(t, []string{"A", "C", "B"}, siblingNames(t, repo, nil))
+
+	for _, f := range []*model.Folder{a, b} {
+		stored, err := repo.FindByID(ctx, f.ID)
+		require.NoError(t, err)
+		assert.Equal(t, f.Version, stored.Version, f.Name)
+		assert.Equal(t, f.SortKey, stored.SortKey, f.Name)
+	}
+}
+
+// TestSortKeyMode_Reorder 测试排序键模式下 ReorderFolder 按位置排序
+func TestSortKeyMode_Reorder(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewServiceWithConfig(repo, sortKeyConfig)
+	ctx := context.Background()
+
+	mustCreateFolder(t, svc, "A", nil)
+	mustCreateFolder(t, svc, "B", nil)
+	c := mustCreateFolder(t, svc, "C", nil)
+
+	require.NoError(t, svc.ReorderFolder(ctx, c.ID, 1))
+	assert.Equal(t, []string{"C", "A", "B"}, siblingNames(t, repo, nil))
+
+	require.NoError(t, svc.ReorderFolder(ctx, c.ID, 99))
+	assert.Equal(t, []string{"A", "B", "C"}, siblingNames(t, repo, nil))
+}
+
+// TestSortKeyMode_AutoRebalance 测试排序键过长时自动重排
+func TestSortKeyMode_AutoRebalance(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewServiceWithConfig(repo, ServiceConfig{OrderMode: OrderModeSortKey, MaxSortKeyLength: 3})
+	ctx := context.Background()
+
+	first := mustCreateFolder(t, svc, "first", nil)
+	last := mustCreateFolder(t, svc, "last", nil)
+
+	// 反复插入到 first 之后，间隙不断缩小
+	expected := []string{"first"}
+	for i := 0; i < 30; i++ {
+		f := mustCreateFolder(t, svc, fmt.Sprintf("n%02d", i), nil)
+		require.NoError(t, svc.PlaceFolder(ctx, &PlaceFolderInput{ID: f.ID, AfterID: &first.ID}))
+		expected = append([]string{"first", f.Name}, expected[1:]...)
+	}
+	// 反复追加到末尾
+	for i := 0; i < 30; i++ {
+		mustCreateFolder(t, svc, fmt.Sprintf("t%02d", i), nil)
+	}
+
+	names := siblingNames(t, repo, nil)
+	assert.Equal(t, expected, names[:31])
+	assert.Equal(t, last.Name, names[31])
+
+	siblings, err := repo.FindByParentID(ctx, nil)
+	require.NoError(t, err)
+	for _, f := range siblings {
+		assert.LessOrEqual(t, len(f.SortKey), 3, f.Name)
+	}
+}
+
+// TestRebalanceAllSortKeys_FromIntegerMode 测试由整数排序号模式迁移到排序键模式
+func TestRebalanceAllSortKeys_FromIntegerMode(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	intSvc := NewService(repo)
+	ctx := context.Background()
+
+	root := mustCreateFolder(t, intSvc, "技术", nil)
+	mustCreateFolder(t, intSvc, "Go", &root.ID)
+	rust := mustCreateFolder(t, intSvc, "Rust", &root.ID)
+	mustCreateFolder(t, intSvc, "生活", nil)
+	require.NoError(t, intSvc.ReorderFolder(ctx, rust.ID, 0))
+
+	keySvc := NewServiceWithConfig(repo, sortKeyConfig)
+	require.NoError(t, keySvc.RebalanceAllSortKeys(ctx))
+
+	assert.Equal(t, []string{"技术", "生活"}, siblingNames(t, repo, nil))
+	assert.Equal(t, []string{"Rust", "Go"}, siblingNames(t, repo, &root.ID))
+
+	all, err := repo.FindAll(ctx)
+	require.NoError(t, err)
+	for _, f := range all {
+		assert.Equal(t, 0, f.SortOrder)
+		assert.NotEmpty(t, f.SortKey)
+	}
+
+	// 迁移后新建节点追加到末尾
+	mustCreateFolder(t, keySvc, "Java", &root.ID)
+	assert.Equal(t, []string{"Rust", "Go", "Java"}, siblingNames(t, repo, &root.ID))
+}
+
+// setSortOrder 直接改写排序号，模拟历史脏数据
+func setSortOrder(t *testing.T, db *gorm.DB, id uint, order int) {
+	t.Helper()
+	require.NoError(t, db.Table(testTableName).Where("id = ?", id).Update("sort_order", order).Error)
+}
+
+// sortOrders 返回父节点下按顺序排列的排序号
+func sortOrders(t *testing.T, repo Repository, parentID *uint) []int {
+	t.Helper()
+	siblings, err := repo.FindByParentID(context.Background(), parentID)
+	require.NoError(t, err)
+	orders := make([]int, 0, len(siblings))
+	for _, s := range siblings {
+		orders = append(orders, s.SortOrder)
+	}
+	return orders
+}
+
+// TestNormalizeSortOrder 测试修复重复、间断和负数排序号
+func TestNormalizeSortOrder(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewGormRepository(db, testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	a := mustCreateFolder(t, svc, "A", nil)
+	b := mustCreateFolder(t, svc, "B", nil)
+	c := mustCreateFolder(t, svc, "C", nil)
+	d := mustCreateFolder(t, svc, "D", nil)
+	setSortOrder(t, db, a.ID, 7)
+	setSortOrder(t, db, b.ID, 7)
+	setSortOrder(t, db, c.ID, -3)
+	setSortOrder(t, db, d.ID, 20)
+
+	before := siblingNames(t, repo, nil)
+	require.NoError(t, svc.NormalizeSortOrder(ctx, nil))
+
+	assert.Equal(t, before, siblingNames(t, repo, nil))
+	assert.Equal(t, []string{"C", "A", "B", "D"}, before)
+	assert.Equal(t, []int{1, 2, 3, 4}, sortOrders(t, repo, nil))
+}
+
+// TestNormalizeAllSortOrders 测试整棵树规范化
+func TestNormalizeAllSortOrders(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewGormRepository(db, testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	root := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &root.ID)
+	rust := mustCreateFolder(t, svc, "Rust", &root.ID)
+	life := mustCreateFolder(t, svc, "生活", nil)
+	setSortOrder(t, db, golang.ID, 5)
+	setSortOrder(t, db, rust.ID, 5)
+	setSortOrder(t, db, life.ID, 0)
+
+	require.NoError(t, svc.NormalizeAllSortOrders(ctx))
+
+	assert.Equal(t, []string{"生活", "技术"}, siblingNames(t, repo, nil))
+	assert.Equal(t, []int{1, 2}, sortOrders(t, repo, nil))
+	assert.Equal(t, []string{"Go", "Rust"}, siblingNames(t, repo, &root.ID))
+	assert.Equal(t, []int{1, 2}, sortOrders(t, repo, &root.ID))
+}
+
+// TestReorderFolder_ShiftsNeighbours 测试排序时顺延兄弟节点而不产生重复
+func TestReorderFolder_ShiftsNeighbours(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	mustCreateFolder(t, svc, "A", nil)
+	b := mustCreateFolder(t, svc, "B", nil)
+	c := mustCreateFolder(t, svc, "C", nil)
+	mustCreateFolder(t, svc, "D", nil)
+
+	require.NoError(t, svc.ReorderFolder(ctx, c.ID, 1))
+	assert.Equal(t, []string{"C", "A", "B", "D"}, siblingNames(t, repo, nil))
+	assert.Equal(t, []int{1, 2, 3, 5}, sortOrders(t, repo, nil))
+
+	require.NoError(t, svc.ReorderFolder(ctx, b.ID, 2))
+	assert.Equal(t, []string{"C", "B", "A", "D"}, siblingNames(t, repo, nil))
+	assert.Equal(t, []int{1, 2, 3, 6}, sortOrders(t, repo, nil))
+
+	// newOrder 为写入的排序号，不按位置截断
+	require.NoError(t, svc.ReorderFolder(ctx, c.ID, 15))
+	assert.Equal(t, []string{"B", "A", "D", "C"}, siblingNames(t, repo, nil))
+	assert.Equal(t, []int{2, 3, 6, 15}, sortOrders(t, repo, nil))
+}
+
+// TestReorderFolderWithVersion_ConcurrentChange 测试版本校验后行被修改时写入排序号失败
+func TestReorderFolderWithVersion_ConcurrentChange(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	a := mustCreateFolder(t, svc, "A", nil)
+	mustCreateFolder(t, svc, "B", nil)
+
+	interleaved := &interleavedRepository{Repository: repo, on: "ShiftSortOrders", change: func(repo Repository) {
+		require.NoError(t, repo.UpdateSortOrder(ctx, a.ID, 9))
+	}}
+	err := NewService(interleaved).ReorderFolderWithVersion(ctx, a.ID, 2, a.Version)
+	assert.ErrorIs(t, err, ErrVersionConflict)
+
+	stored, err := repo.FindByID(ctx, a.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.SortOrder)
+}
+
+// TestSortKeyMode_ReorderWithVersion_ConcurrentChange 测试排序键模式下版本校验后行被修改时写入失败
+func TestSortKeyMode_ReorderWithVersion_ConcurrentChange(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewServiceWithConfig(repo, sortKeyConfig)
+	ctx := context.Background()
+
+	mustCreateFolder(t, svc, "A", nil)
+	b := mustCreateFolder(t, svc, "B", nil)
+
+	interleaved := &interleavedRepository{Repository: repo, on: "FindByParentID", change: func(repo Repository) {
+		require.NoError(t, repo.UpdateSortKey(ctx, b.ID, "z"))
+	}}
+	err := NewServiceWithConfig(interleaved, sortKeyConfig).ReorderFolderWithVersion(ctx, b.ID, 1, b.Version)
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	assert.Equal(t, []string{"A", "B"}, siblingNames(t, repo, nil))
+
+	require.NoError(t, svc.ReorderFolderWithVersion(ctx, b.ID, 1, b.Version))
+	assert.Equal(t, []string{"B", "A"}, siblingNames(t, repo, nil))
+}
+
+// interleavedRepository 在首次调用名为 on 的方法前执行 change，模拟读取与写入之间提交的并发修改
+type interleavedRepository struct {
+	Repository
+	on     string
+	change func(repo Repository)
+}
+
+func (r *interleavedRepository) WithTx(ctx context.Context, fn func(repo Repository) error) error {
+	return r.Repository.WithTx(ctx, func(repo Repository) error {
+		return fn(&interleavedRepository{Repository: repo, on: r.on, change: r.change})
+	})
+}
+
+func (r *interleavedRepository) interleave(method string) {
+	if method == r.on && r.change != nil {
+		r.change(r.Repository)
+		r.change = nil
+	}
+}
+
+func (r *interleavedRepository) ShiftSortOrders(ctx context.Context, parentID *uint, from int, excludeID uint) error {
+	r.interleave("ShiftSortOrders")
+	return r.Repository.ShiftSortOrders(ctx, parentID, from, excludeID)
+}
+
+func (r *interleavedRepository) FindByParentID(ctx context.Context, parentID *uint) ([]*model.Folder, error) {
+	r.interleave("FindByParentID")
+	return r.Repository.FindByParentID(ctx, parentID)
+}
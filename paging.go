@@ -0,0 +1,101 @@
+package folder
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/KOMKZ/go-yogan-domain-folder/model"
+)
+
+const (
+	defaultChildrenPageSize = 50
+	maxChildrenPageSize     = 500
+)
+
+// ChildCursor 子节点分页位置，对应同级排序 (sort_order, sort_key, id)
+type ChildCursor struct {
+	SortOrder int    `json:"o"`
+	SortKey   string `json:"k,omitempty"`
+	ID        uint   `json:"i"`
+}
+
+// ChildrenPageOptions 子节点分页选项
+type ChildrenPageOptions struct {
+	Cursor    string // 上一页返回的 NextCursor，为空表示第一页
+	PageSize  int    // 每页条数，默认 50，最大 500
+	WithTotal bool   // 同时返回子节点总数（额外一次 COUNT 查询）
+}
+
+// ChildrenPage 子节点分页结果
+type ChildrenPage struct {
+	Items      []*model.Folder `json:"items"`
+	NextCursor string          `json:"nextCursor,omitempty"` // 没有下一页时为空
+	HasMore    bool            `json:"hasMore"`
+	Total      *int64          `json:"total,omitempty"`
+}
+
+// GetChildrenPage 分页获取子节点，顺序与 GetChildren 一致
+// 使用不透明游标做键集分页，翻页期间删除或追加节点不会导致重复或遗漏；整数模式下重排兄弟顺序会改变排序号，此时应从第一页重新开始
+func (s *Service) GetChildrenPage(ctx context.Context, parentID *uint, opts *ChildrenPageOptions) (*ChildrenPage, error) {
+	if opts == nil {
+		opts = &ChildrenPageOptions{}
+	}
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultChildrenPageSize
+	}
+	if pageSize > maxChildrenPageSize {
+		pageSize = maxChildrenPageSize
+	}
+
+	var after *ChildCursor
+	if opts.Cursor != "" {
+		var err error
+		if after, err = decodeChildCursor(opts.Cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	// 多取一条判断是否还有下一页
+	folders, err := s.repo.FindByParentIDAfter(ctx, parentID, after, pageSize+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &ChildrenPage{Items: folders}
+	if len(folders) > pageSize {
+		page.Items = folders[:pageSize]
+		page.HasMore = true
+		last := page.Items[pageSize-1]
+		page.NextCursor = encodeChildCursor(&ChildCursor{SortOrder: last.SortOrder, SortKey: last.SortKey, ID: last.ID})
+	}
+
+	if opts.WithTotal {
+		total, err := s.repo.CountByParentID(ctx, parentID)
+		if err != nil {
+			return nil, err
+		}
+		page.Total = &total
+	}
+	return page, nil
+}
+
+// encodeChildCursor 编码游标
+func encodeChildCursor(cursor *ChildCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeChildCursor 解码游标，格式错误时返回 ErrInvalidCursor
+func decodeChildCursor(s string) (*ChildCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor ChildCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
@@ -0,0 +1,143 @@
+package folder
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pageNames 返回分页结果的名称
+func pageNames(page *ChildrenPage) []string {
+	names := make([]string, 0, len(page.Items))
+	for _, f := range page.Items {
+		names = append(names, f.Name)
+	}
+	return names
+}
+
+// TestGetChildrenPage_WalksAllPages 测试游标翻页覆盖所有子节点且顺序一致
+func TestGetChildrenPage_WalksAllPages(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewGormRepository(db, testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	root := mustCreateFolder(t, svc, "root", nil)
+	other := mustCreateFolder(t, svc, "other", nil)
+	var children []uint
+	for i := 1; i <= 7; i++ {
+		children = append(children, mustCreateFolder(t, svc, fmt.Sprintf("c%d", i), &root.ID).ID)
+	}
+	mustCreateFolder(t, svc, "x", &other.ID)
+	// 排序号相同的兄弟按 id 排序
+	setSortOrder(t, db, children[4], 2)
+
+	var names []string
+	cursor := ""
+	pages := 0
+	for {
+		page, err := svc.GetChildrenPage(ctx, &root.ID, &ChildrenPageOptions{Cursor: cursor, PageSize: 3})
+		require.NoError(t, err)
+		names = append(names, pageNames(page)...)
+		pages++
+		if !page.HasMore {
+			assert.Empty(t, page.NextCursor)
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	assert.Equal(t, 3, pages)
+	assert.Equal(t, siblingNames(t, repo, &root.ID), names)
+}
+
+// TestGetChildrenPage_Total 测试返回总数
+func TestGetChildrenPage_Total(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	for i := 1; i <= 3; i++ {
+		mustCreateFolder(t, svc, fmt.Sprintf("r%d", i), nil)
+	}
+
+	page, err := svc.GetChildrenPage(ctx, nil, &ChildrenPageOptions{PageSize: 2, WithTotal: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"r1", "r2"}, pageNames(page))
+	require.NotNil(t, page.Total)
+	assert.Equal(t, int64(3), *page.Total)
+
+	page, err = svc.GetChildrenPage(ctx, nil, nil)
+	require.NoError(t, err)
+	assert.Len(t, page.Items, 3)
+	assert.False(t, page.HasMore)
+	assert.Nil(t, page.Total)
+}
+
+// TestGetChildrenPage_StableUnderChanges 测试翻页期间删除和追加节点不导致重复或遗漏
+func TestGetChildrenPage_StableUnderChanges(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	var ids []uint
+	for i := 1; i <= 4; i++ {
+		ids = append(ids, mustCreateFolder(t, svc, fmt.Sprintf("r%d", i), nil).ID)
+	}
+
+	page, err := svc.GetChildrenPage(ctx, nil, &ChildrenPageOptions{PageSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"r1", "r2"}, pageNames(page))
+
+	// 删除已读过的节点并追加新节点
+	require.NoError(t, svc.DeleteFolder(ctx, ids[0]))
+	mustCreateFolder(t, svc, "r5", nil)
+
+	page, err = svc.GetChildrenPage(ctx, nil, &ChildrenPageOptions{Cursor: page.NextCursor, PageSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"r3", "r4"}, pageNames(page))
+
+	page, err = svc.GetChildrenPage(ctx, nil, &ChildrenPageOptions{Cursor: page.NextCursor, PageSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"r5"}, pageNames(page))
+}
+
+// TestGetChildrenPage_SortKeyMode 测试排序键模式下的翻页
+func TestGetChildrenPage_SortKeyMode(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewServiceWithConfig(repo, sortKeyConfig)
+	ctx := context.Background()
+
+	var ids []uint
+	for i := 1; i <= 5; i++ {
+		ids = append(ids, mustCreateFolder(t, svc, fmt.Sprintf("k%d", i), nil).ID)
+	}
+	require.NoError(t, svc.PlaceFolder(ctx, &PlaceFolderInput{ID: ids[4], BeforeID: &ids[0]}))
+
+	var names []string
+	cursor := ""
+	for {
+		page, err := svc.GetChildrenPage(ctx, nil, &ChildrenPageOptions{Cursor: cursor, PageSize: 2})
+		require.NoError(t, err)
+		names = append(names, pageNames(page)...)
+		if !page.HasMore {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	assert.Equal(t, []string{"k5", "k1", "k2", "k3", "k4"}, names)
+}
+
+// TestGetChildrenPage_InvalidCursor 测试无效游标
+func TestGetChildrenPage_InvalidCursor(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+
+	for _, cursor := range []string{"!!!", "bm90LWpzb24", encodeChildCursor(&ChildCursor{})} {
+		_, err := svc.GetChildrenPage(context.Background(), nil, &ChildrenPageOptions{Cursor: cursor})
+		assert.ErrorIs(t, err, ErrInvalidCursor, cursor)
+	}
+}
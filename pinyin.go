@@ -0,0 +1,66 @@
+package folder
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/KOMKZ/go-yogan-domain-folder/model"
+	"github.com/mozillazg/go-pinyin"
+)
+
+// pinyinArgs 拼音转换参数：不带声调，非汉字原样保留
+var pinyinArgs = func() pinyin.Args {
+	args := pinyin.NewArgs()
+	args.Fallback = func(r rune, _ pinyin.Args) []string {
+		return []string{string(r)}
+	}
+	return args
+}()
+
+// namePinyin 计算名称的全拼与首字母，如 "技术文章" -> "jishuwenzhang", "jswz"
+// 字母和数字原样保留（转为小写），空白与标点被忽略；多音字取常用读音
+func namePinyin(name string) (full, initials string) {
+	var fullBuilder, initialsBuilder strings.Builder
+	for _, syllables := range pinyin.Pinyin(name, pinyinArgs) {
+		if len(syllables) == 0 {
+			continue
+		}
+		s := strings.ToLower(syllables[0])
+		r := []rune(s)
+		if len(r) == 1 && !unicode.IsLetter(r[0]) && !unicode.IsDigit(r[0]) {
+			continue
+		}
+		fullBuilder.WriteString(s)
+		initialsBuilder.WriteRune(r[0])
+	}
+	return fullBuilder.String(), initialsBuilder.String()
+}
+
+// indexPinyin 启用拼音索引时，根据名称更新文件夹的拼音字段
+func (s *Service) indexPinyin(folder *model.Folder) {
+	if !s.config.PinyinIndex {
+		return
+	}
+	folder.Pinyin, folder.PinyinInitials = namePinyin(folder.Name)
+}
+
+// RebuildPinyinIndex 为所有文件夹重新计算拼音索引，用于启用拼音索引前已存在的数据
+func (s *Service) RebuildPinyinIndex(ctx context.Context) error {
+	return s.repo.WithTx(ctx, func(repo Repository) error {
+		folders, err := repo.FindAll(ctx)
+		if err != nil {
+			return err
+		}
+		for _, f := range folders {
+			full, initials := namePinyin(f.Name)
+			if full == f.Pinyin && initials == f.PinyinInitials {
+				continue
+			}
+			if err := repo.UpdatePinyin(ctx, f.ID, full, initials); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
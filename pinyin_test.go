@@ -0,0 +1,109 @@
+package folder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNamePinyin 测试拼音与首字母计算
+func TestNamePinyin(t *testing.T) {
+	tests := []struct {
+		name     string
+		full     string
+		initials string
+	}{
+		{"技术文章", "jishuwenzhang", "jswz"},
+		{"Go语言", "goyuyan", "goyy"},
+		{"前端 & 后端", "qianduanhouduan", "qdhd"},
+		{"2024 总结", "2024zongjie", "2024zj"},
+		{"", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			full, initials := namePinyin(tt.name)
+			assert.Equal(t, tt.full, full)
+			assert.Equal(t, tt.initials, initials)
+		})
+	}
+}
+
+// TestPinyinIndex_MaintainedOnCreateAndRename 测试创建和重命名时维护拼音索引
+func TestPinyinIndex_MaintainedOnCreateAndRename(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewServiceWithConfig(repo, ServiceConfig{PinyinIndex: true})
+	ctx := context.Background()
+
+	f := mustCreateFolder(t, svc, "技术文章", nil)
+	stored, err := repo.FindByID(ctx, f.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "jishuwenzhang", stored.Pinyin)
+	assert.Equal(t, "jswz", stored.PinyinInitials)
+
+	_, err = svc.UpdateFolder(ctx, &UpdateFolderInput{ID: f.ID, Name: "生活随笔"})
+	require.NoError(t, err)
+	stored, err = repo.FindByID(ctx, f.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "shenghuosuibi", stored.Pinyin)
+	assert.Equal(t, "shsb", stored.PinyinInitials)
+}
+
+// TestPinyinIndex_Disabled 测试未启用时不维护拼音，搜索只匹配名称
+func TestPinyinIndex_Disabled(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	f := mustCreateFolder(t, svc, "技术文章", nil)
+	assert.Empty(t, f.Pinyin)
+
+	result, err := svc.SearchFolders(ctx, "jswz", nil)
+	require.NoError(t, err)
+	assert.Empty(t, result.Items)
+}
+
+// TestSearchFolders_Pinyin 测试按全拼和首字母搜索
+func TestSearchFolders_Pinyin(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewServiceWithConfig(repo, ServiceConfig{PinyinIndex: true})
+	ctx := context.Background()
+
+	mustCreateFolder(t, svc, "技术文章", nil)
+	mustCreateFolder(t, svc, "生活", nil)
+
+	for _, query := range []string{"jswz", "JiShu", "ji shu", "wenzhang", "技术"} {
+		result, err := svc.SearchFolders(ctx, query, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"技术文章"}, hitNames(result), query)
+	}
+
+	result, err := svc.SearchFolders(ctx, "wenzhang", &SearchOptions{Match: MatchPrefix})
+	require.NoError(t, err)
+	assert.Empty(t, result.Items)
+
+	result, err = svc.SearchFolders(ctx, "sh", &SearchOptions{Match: MatchPrefix})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"生活"}, hitNames(result))
+}
+
+// TestRebuildPinyinIndex 测试为已有数据重建拼音索引
+func TestRebuildPinyinIndex(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	ctx := context.Background()
+
+	f := mustCreateFolder(t, NewService(repo), "技术文章", nil)
+	svc := NewServiceWithConfig(repo, ServiceConfig{PinyinIndex: true})
+	require.NoError(t, svc.RebuildPinyinIndex(ctx))
+
+	stored, err := repo.FindByID(ctx, f.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "jswz", stored.PinyinInitials)
+	assert.Equal(t, f.Version, stored.Version)
+
+	result, err := svc.SearchFolders(ctx, "jswz", nil)
+	require.NoError(t, err)
+	assert.Len(t, result.Items, 1)
+}
@@ -2,33 +2,55 @@ package folder
 
 import (
 	"context"
+	"time"
 
 	"github.com/KOMKZ/go-yogan-domain-folder/model"
 )
 
 // Repository 文件夹仓储接口
 type Repository interface {
+	// 事务：fn 返回错误时回滚，fn 内必须使用传入的 repo
+	WithTx(ctx context.Context, fn func(repo Repository) error) error
+
 	// 基础 CRUD
 	Create(ctx context.Context, folder *model.Folder) error
 	Update(ctx context.Context, folder *model.Folder) error
 	Delete(ctx context.Context, id uint) error
+	DeleteByIDs(ctx context.Context, ids []uint) error
 	FindByID(ctx context.Context, id uint) (*model.Folder, error)
+	FindByIDs(ctx context.Context, ids []uint) ([]*model.Folder, error)
 
 	// 层级查询
 	FindByParentID(ctx context.Context, parentID *uint) ([]*model.Folder, error)
+	FindByParentIDAfter(ctx context.Context, parentID *uint, after *ChildCursor, limit int) ([]*model.Folder, error)
+	CountByParentID(ctx context.Context, parentID *uint) (int64, error)
+	CountChildrenAtDepth(ctx context.Context, pathPrefix string, depth int) (map[uint]int, error)
+	CountDescendants(ctx context.Context, id uint) (int64, error)
 	FindChildren(ctx context.Context, parentID uint) ([]*model.Folder, error)
 	FindRoots(ctx context.Context) ([]*model.Folder, error)
+	FindByNameAndParent(ctx context.Context, name string, parentID *uint) (*model.Folder, error)
 
 	// 路径查询
 	FindByPath(ctx context.Context, pathPrefix string) ([]*model.Folder, error)
+	FindByPathMaxDepth(ctx context.Context, pathPrefix string, maxDepth int) ([]*model.Folder, error)
 	FindAncestors(ctx context.Context, path string) ([]*model.Folder, error)
+	FindByNamePath(ctx context.Context, names []string) (*model.Folder, error)
 
 	// 全量查询
 	FindAll(ctx context.Context) ([]*model.Folder, error)
 
+	// 搜索：返回当前页结果及匹配总数
+	SearchByName(ctx context.Context, query *NameQuery) ([]*model.Folder, int64, error)
+
 	// 排序
 	UpdateSortOrder(ctx context.Context, id uint, sortOrder int) error
+	ShiftSortOrders(ctx context.Context, parentID *uint, from int, excludeID uint) error
 	FindMaxSortOrder(ctx context.Context, parentID *uint) (int, error)
+	UpdateSortKey(ctx context.Context, id uint, sortKey string) error
+	FindMaxSortKey(ctx context.Context, parentID *uint) (string, error)
+
+	// 拼音索引
+	UpdatePinyin(ctx context.Context, id uint, full, initials string) error
 
 	// 批量更新
 	UpdatePathAndDepth(ctx context.Context, id uint, path string, depth int) error
@@ -37,4 +59,13 @@ type Repository interface {
 	// 检查
 	ExistsByNameAndParent(ctx context.Context, name string, parentID *uint, excludeID *uint) (bool, error)
 	HasChildren(ctx context.Context, id uint) (bool, error)
+
+	// 回收站（包含已软删除的记录）
+	FindDeleted(ctx context.Context) ([]*model.Folder, error)
+	FindDeletedByID(ctx context.Context, id uint) (*model.Folder, error)
+	FindDeletedByPath(ctx context.Context, pathPrefix string) ([]*model.Folder, error)
+	FindByIDsUnscoped(ctx context.Context, ids []uint) ([]*model.Folder, error)
+	Restore(ctx context.Context, folder *model.Folder) error
+	RestoreByIDs(ctx context.Context, ids []uint) error
+	PurgeDeletedBefore(ctx context.Context, before time.Time) (int64, error)
 }
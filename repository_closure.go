@@ -0,0 +1,287 @@
+package folder
+
+import (
+	"context"
+	"time"
+
+	"github.com/KOMKZ/go-yogan-domain-folder/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ClosureRepository 基于闭包表的 Repository 实现
+// 在 GormRepository 的基础上维护 <table>_closure (ancestor, descendant, distance)，
+// 子孙与祖先查询走闭包表而不是 path 前缀匹配；path、depth 列仍照常维护，以便与 Service 配合
+type ClosureRepository struct {
+	*GormRepository
+	closureTable string
+}
+
+// NewClosureRepository 创建闭包表 Repository，闭包表名为 tableName + "_closure"
+func NewClosureRepository(db *gorm.DB, tableName string, opts ...GormOption) *ClosureRepository {
+	return &ClosureRepository{
+		GormRepository: NewGormRepository(db, tableName, opts...),
+		closureTable:   tableName + "_closure",
+	}
+}
+
+// ClosureTableName 闭包表名
+func (r *ClosureRepository) ClosureTableName() string {
+	return r.closureTable
+}
+
+// MigrateClosureTable 创建闭包表
+func (r *ClosureRepository) MigrateClosureTable(ctx context.Context) error {
+	return r.db.WithContext(ctx).Table(r.closureTable).AutoMigrate(&model.FolderClosure{})
+}
+
+// RebuildClosure 根据 parent_id 重建当前作用域内（包括回收站中）所有节点的闭包记录
+// 用于从纯物化路径的表迁移，或修复闭包表与 parent_id 不一致的数据
+func (r *ClosureRepository) RebuildClosure(ctx context.Context) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		repo := r.withDB(tx)
+
+		var folders []*model.Folder
+		if err := repo.table(ctx).Unscoped().Select("id", "parent_id").Find(&folders).Error; err != nil {
+			return err
+		}
+		if len(folders) == 0 {
+			return nil
+		}
+
+		parents := make(map[uint]*uint, len(folders))
+		ids := make([]uint, 0, len(folders))
+		for _, f := range folders {
+			parents[f.ID] = f.ParentID
+			ids = append(ids, f.ID)
+		}
+		if err := repo.closure(ctx).Where("descendant IN ?", ids).Delete(&model.FolderClosure{}).Error; err != nil {
+			return err
+		}
+
+		rows := make([]*model.FolderClosure, 0, len(folders))
+		for _, f := range folders {
+			// 沿 parent_id 向上，遇到环或缺失的父节点时停止
+			visited := map[uint]bool{f.ID: true}
+			rows = append(rows, &model.FolderClosure{Ancestor: f.ID, Descendant: f.ID})
+			for distance, parent := 1, f.ParentID; parent != nil && !visited[*parent]; distance++ {
+				grandparent, ok := parents[*parent]
+				if !ok {
+					break
+				}
+				visited[*parent] = true
+				rows = append(rows, &model.FolderClosure{Ancestor: *parent, Descendant: f.ID, Distance: distance})
+				parent = grandparent
+			}
+		}
+		return repo.closure(ctx).CreateInBatches(rows, 500).Error
+	})
+}
+
+// closure 返回闭包表的 DB 实例
+func (r *ClosureRepository) closure(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Table(r.closureTable)
+}
+
+// subtreeIDs 返回 id 的子树（包含自身）节点 ID 的子查询
+func (r *ClosureRepository) subtreeIDs(ctx context.Context, id uint) *gorm.DB {
+	return r.closure(ctx).Select("descendant").Where("ancestor = ?", id)
+}
+
+// lastPathID 返回路径中最后一级的 ID
+func lastPathID(path string) (uint, bool) {
+	ids := parsePathIDs(path)
+	if len(ids) == 0 {
+		return 0, false
+	}
+	return ids[len(ids)-1], true
+}
+
+// WithTx 在数据库事务中执行 fn
+func (r *ClosureRepository) WithTx(ctx context.Context, fn func(repo Repository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(r.withDB(tx))
+	})
+}
+
+// withDB 返回使用指定 DB 实例的仓储副本
+func (r *ClosureRepository) withDB(db *gorm.DB) *ClosureRepository {
+	return &ClosureRepository{
+		GormRepository: r.GormRepository.withDB(db),
+		closureTable:   r.closureTable,
+	}
+}
+
+// Create 创建文件夹并写入闭包记录：自身，以及父节点的每个祖先
+func (r *ClosureRepository) Create(ctx context.Context, folder *model.Folder) error {
+	if err := r.GormRepository.Create(ctx, folder); err != nil {
+		return err
+	}
+	if err := r.closure(ctx).Create(&model.FolderClosure{Ancestor: folder.ID, Descendant: folder.ID}).Error; err != nil {
+		return err
+	}
+	if folder.ParentID == nil {
+		return nil
+	}
+	return r.db.WithContext(ctx).Exec(
+		"INSERT INTO ? (ancestor, descendant, distance) SELECT ancestor, ?, distance + 1 FROM ? WHERE descendant = ?",
+		clause.Table{Name: r.closureTable}, folder.ID, clause.Table{Name: r.closureTable}, *folder.ParentID,
+	).Error
+}
+
+// Update 更新文件夹，父节点变化时同步移动闭包表中的子树
+func (r *ClosureRepository) Update(ctx context.Context, folder *model.Folder) error {
+	oldParentID, err := r.storedParentID(ctx, folder.ID)
+	if err != nil {
+		return err
+	}
+	if err := r.GormRepository.Update(ctx, folder); err != nil {
+		return err
+	}
+	if sameParent(oldParentID, folder.ParentID) {
+		return nil
+	}
+	return r.moveSubtree(ctx, folder.ID, folder.ParentID)
+}
+
+// Restore 恢复文件夹，恢复到其他父节点时同步移动闭包表中的子树
+func (r *ClosureRepository) Restore(ctx context.Context, folder *model.Folder) error {
+	oldParentID, err := r.storedParentID(ctx, folder.ID)
+	if err != nil {
+		return err
+	}
+	if err := r.GormRepository.Restore(ctx, folder); err != nil {
+		return err
+	}
+	if sameParent(oldParentID, folder.ParentID) {
+		return nil
+	}
+	return r.moveSubtree(ctx, folder.ID, folder.ParentID)
+}
+
+// moveSubtree 将 id 的子树挂到 newParentID 下：
+// 先断开子树与原祖先的关联，再为新父节点的每个祖先与子树的每个节点建立关联
+func (r *ClosureRepository) moveSubtree(ctx context.Context, id uint, newParentID *uint) error {
+	// MySQL 不允许在 DELETE 的子查询中引用目标表，先取出子树节点
+	var subtree []uint
+	if err := r.subtreeIDs(ctx, id).Pluck("descendant", &subtree).Error; err != nil {
+		return err
+	}
+	if len(subtree) == 0 {
+		subtree = []uint{id}
+	}
+	if err := r.closure(ctx).
+		Where("descendant IN ?", subtree).
+		Where("ancestor NOT IN ?", subtree).
+		Delete(&model.FolderClosure{}).Error; err != nil {
+		return err
+	}
+	if newParentID == nil {
+		return nil
+	}
+	return r.db.WithContext(ctx).Exec(
+		"INSERT INTO ? (ancestor, descendant, distance) "+
+			"SELECT a.ancestor, d.descendant, a.distance + d.distance + 1 FROM ? AS a CROSS JOIN ? AS d "+
+			"WHERE a.descendant = ? AND d.ancestor = ?",
+		clause.Table{Name: r.closureTable}, clause.Table{Name: r.closureTable}, clause.Table{Name: r.closureTable},
+		*newParentID, id,
+	).Error
+}
+
+// PurgeDeletedBefore 永久删除在 before 之前进入回收站的文件夹，并清理其闭包记录
+func (r *ClosureRepository) PurgeDeletedBefore(ctx context.Context, before time.Time) (int64, error) {
+	purged, err := r.GormRepository.PurgeDeletedBefore(ctx, before)
+	if err != nil || purged == 0 {
+		return purged, err
+	}
+	existing := r.db.WithContext(ctx).Table(r.tableName).Select("id")
+	err = r.closure(ctx).
+		Where("descendant NOT IN (?) OR ancestor NOT IN (?)", existing, existing).
+		Delete(&model.FolderClosure{}).Error
+	return purged, err
+}
+
+// FindByPath 查询路径最后一级节点的子树（包含自身）
+func (r *ClosureRepository) FindByPath(ctx context.Context, pathPrefix string) ([]*model.Folder, error) {
+	id, ok := lastPathID(pathPrefix)
+	if !ok {
+		return r.GormRepository.FindByPath(ctx, pathPrefix)
+	}
+	var folders []*model.Folder
+	err := r.table(ctx).
+		Where("id IN (?)", r.subtreeIDs(ctx, id)).
+		Order("depth ASC, sort_order ASC, sort_key ASC, id ASC").
+		Find(&folders).Error
+	return folders, err
+}
+
+// FindByPathMaxDepth 查询路径最后一级节点的子树中深度不超过 maxDepth 的节点
+func (r *ClosureRepository) FindByPathMaxDepth(ctx context.Context, pathPrefix string, maxDepth int) ([]*model.Folder, error) {
+	id, ok := lastPathID(pathPrefix)
+	if !ok {
+		return r.GormRepository.FindByPathMaxDepth(ctx, pathPrefix, maxDepth)
+	}
+	var folders []*model.Folder
+	err := r.table(ctx).
+		Where("id IN (?)", r.subtreeIDs(ctx, id)).
+		Where("depth <= ?", maxDepth).
+		Order("depth ASC, sort_order ASC, sort_key ASC, id ASC").
+		Find(&folders).Error
+	return folders, err
+}
+
+// FindAncestors 查询路径最后一级节点的所有祖先（包含自身），从根开始排列
+func (r *ClosureRepository) FindAncestors(ctx context.Context, path string) ([]*model.Folder, error) {
+	id, ok := lastPathID(path)
+	if !ok {
+		return []*model.Folder{}, nil
+	}
+	var folders []*model.Folder
+	err := r.table(ctx).
+		Where("id IN (?)", r.closure(ctx).Select("ancestor").Where("descendant = ?", id)).
+		Order("depth ASC").
+		Find(&folders).Error
+	return folders, err
+}
+
+// CountDescendants 统计子孙节点数量（不含自身）
+func (r *ClosureRepository) CountDescendants(ctx context.Context, id uint) (int64, error) {
+	if _, err := r.FindByID(ctx, id); err != nil {
+		return 0, err
+	}
+	var count int64
+	err := r.aggregate(ctx).
+		Where("id IN (?)", r.closure(ctx).Select("descendant").Where("ancestor = ? AND distance > 0", id)).
+		Count(&count).Error
+	return count, err
+}
+
+// UpdateChildrenPathAndDepth 批量更新子孙节点的路径和深度，子孙节点由闭包表确定而不是 path 前缀匹配
+func (r *ClosureRepository) UpdateChildrenPathAndDepth(ctx context.Context, oldPathPrefix, newPathPrefix string, depthDiff int) error {
+	id, ok := lastPathID(oldPathPrefix)
+	if !ok {
+		return r.GormRepository.UpdateChildrenPathAndDepth(ctx, oldPathPrefix, newPathPrefix, depthDiff)
+	}
+	return r.table(ctx).
+		Where("id IN (?)", r.closure(ctx).Select("descendant").Where("ancestor = ? AND distance > 0", id)).
+		Updates(map[string]interface{}{
+			"path":    r.rewritePathPrefix(oldPathPrefix, newPathPrefix),
+			"depth":   gorm.Expr("depth + ?", depthDiff),
+			"version": gorm.Expr("version + 1"),
+		}).Error
+}
+
+// FindDeletedByPath 查询路径最后一级节点子树中已删除的节点（包含自身）
+func (r *ClosureRepository) FindDeletedByPath(ctx context.Context, pathPrefix string) ([]*model.Folder, error) {
+	id, ok := lastPathID(pathPrefix)
+	if !ok {
+		return r.GormRepository.FindDeletedByPath(ctx, pathPrefix)
+	}
+	var folders []*model.Folder
+	err := r.table(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Where("id IN (?)", r.subtreeIDs(ctx, id)).
+		Order("depth ASC, sort_order ASC, sort_key ASC, id ASC").
+		Find(&folders).Error
+	return folders, err
+}
@@ -0,0 +1,255 @@
+package folder
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/KOMKZ/go-yogan-domain-folder/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newClosureTestRepo 创建闭包表 Repository 并建表
+func newClosureTestRepo(t *testing.T) (*gorm.DB, *ClosureRepository) {
+	t.Helper()
+	db := newTestDB(t)
+	repo := NewClosureRepository(db, testTableName)
+	require.NoError(t, repo.MigrateClosureTable(context.Background()))
+	return db, repo
+}
+
+// assertClosureConsistent 校验闭包表与 parent_id（包括回收站中的记录）一致
+func assertClosureConsistent(t *testing.T, db *gorm.DB, repo *ClosureRepository) {
+	t.Helper()
+	var folders []*model.Folder
+	require.NoError(t, db.Table(testTableName).Unscoped().Find(&folders).Error)
+	parents := make(map[uint]*uint, len(folders))
+	for _, f := range folders {
+		parents[f.ID] = f.ParentID
+	}
+	expected := make(map[string]int)
+	for _, f := range folders {
+		expected[fmt.Sprintf("%d-%d", f.ID, f.ID)] = 0
+		for distance, parent := 1, f.ParentID; parent != nil; distance++ {
+			expected[fmt.Sprintf("%d-%d", *parent, f.ID)] = distance
+			parent = parents[*parent]
+		}
+	}
+
+	var rows []*model.FolderClosure
+	require.NoError(t, db.Table(repo.ClosureTableName()).Find(&rows).Error)
+	actual := make(map[string]int, len(rows))
+	for _, row := range rows {
+		actual[fmt.Sprintf("%d-%d", row.Ancestor, row.Descendant)] = row.Distance
+	}
+	assert.Equal(t, expected, actual)
+}
+
+// TestClosureRepository_CreateAndQuery 测试创建节点维护闭包记录，并按闭包表查询子树与祖先
+func TestClosureRepository_CreateAndQuery(t *testing.T) {
+	db, repo := newClosureTestRepo(t)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	tech := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &tech.ID)
+	concurrency := mustCreateFolder(t, svc, "并发", &golang.ID)
+	mustCreateFolder(t, svc, "Rust", &tech.ID)
+	mustCreateFolder(t, svc, "生活", nil)
+	assertClosureConsistent(t, db, repo)
+
+	ancestors, err := svc.GetAncestors(ctx, concurrency.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"技术", "Go", "并发"}, folderNames(ancestors))
+
+	// 子孙查询不依赖子孙节点的 path 列
+	corrupt(t, db, concurrency.ID, "path", "/stale/")
+	nodes, err := svc.GetSubTree(ctx, tech.ID)
+	require.NoError(t, err)
+	require.Len(t, nodes, 2)
+	assert.Equal(t, "Go", nodes[0].Name)
+	assert.Equal(t, 1, nodes[0].DescendantCount)
+
+	count, err := svc.CountDescendants(ctx, golang.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+// TestClosureRepository_Move 测试移动子树
+func TestClosureRepository_Move(t *testing.T) {
+	db, repo := newClosureTestRepo(t)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	tech := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &tech.ID)
+	concurrency := mustCreateFolder(t, svc, "并发", &golang.ID)
+	channels := mustCreateFolder(t, svc, "Channel", &concurrency.ID)
+	archive := mustCreateFolder(t, svc, "归档", nil)
+	old := mustCreateFolder(t, svc, "旧文", &archive.ID)
+
+	require.NoError(t, svc.MoveFolder(ctx, golang.ID, &old.ID))
+	assertClosureConsistent(t, db, repo)
+
+	ancestors, err := svc.GetAncestors(ctx, channels.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"归档", "旧文", "Go", "并发", "Channel"}, folderNames(ancestors))
+
+	stored, err := repo.FindByID(ctx, channels.ID)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("/%d/%d/%d/%d/%d/", archive.ID, old.ID, golang.ID, concurrency.ID, channels.ID), stored.Path)
+	assert.Equal(t, 4, stored.Depth)
+
+	count, err := svc.CountDescendants(ctx, tech.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+
+	// 移到根节点
+	require.NoError(t, svc.MoveFolder(ctx, concurrency.ID, nil))
+	assertClosureConsistent(t, db, repo)
+
+	ancestors, err = svc.GetAncestors(ctx, channels.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"并发", "Channel"}, folderNames(ancestors))
+
+	// 不能移动到自己的子孙下
+	err = svc.MoveFolder(ctx, concurrency.ID, &channels.ID)
+	assert.Error(t, err)
+	assertClosureConsistent(t, db, repo)
+}
+
+// TestClosureRepository_UpdateChildrenPathAndDepth 测试子孙节点由闭包表确定，不依赖 path 前缀匹配
+func TestClosureRepository_UpdateChildrenPathAndDepth(t *testing.T) {
+	db, repo := newClosureTestRepo(t)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	tech := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &tech.ID)
+	concurrency := mustCreateFolder(t, svc, "并发", &golang.ID)
+	life := mustCreateFolder(t, svc, "生活", nil)
+
+	recorder := &sqlRecorder{Interface: logger.Default.LogMode(logger.Silent)}
+	recorded := NewClosureRepository(db.Session(&gorm.Session{Logger: recorder}), testTableName)
+
+	newPath := fmt.Sprintf("/%d/%d/", life.ID, golang.ID)
+	require.NoError(t, recorded.UpdateChildrenPathAndDepth(ctx, golang.Path, newPath, 0))
+	require.Len(t, recorder.statements, 1)
+	assert.Contains(t, recorder.statements[0], recorded.ClosureTableName())
+	assert.NotContains(t, recorder.statements[0], "LIKE")
+
+	stored, err := repo.FindByID(ctx, concurrency.ID)
+	require.NoError(t, err)
+	assert.Equal(t, newPath+fmt.Sprintf("%d/", concurrency.ID), stored.Path)
+	stored, err = repo.FindByID(ctx, golang.ID)
+	require.NoError(t, err)
+	assert.Equal(t, golang.Path, stored.Path)
+	stored, err = repo.FindByID(ctx, tech.ID)
+	require.NoError(t, err)
+	assert.Equal(t, tech.Path, stored.Path)
+}
+
+// TestClosureRepository_DeleteRestorePurge 测试删除策略、回收站恢复与永久删除
+func TestClosureRepository_DeleteRestorePurge(t *testing.T) {
+	db, repo := newClosureTestRepo(t)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	tech := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &tech.ID)
+	concurrency := mustCreateFolder(t, svc, "并发", &golang.ID)
+	channels := mustCreateFolder(t, svc, "Channel", &concurrency.ID)
+	life := mustCreateFolder(t, svc, "生活", nil)
+	travel := mustCreateFolder(t, svc, "旅行", &life.ID)
+
+	// 子节点上移，更深层的子孙节点随之更新路径
+	_, err := svc.DeleteFolderWithOptions(ctx, golang.ID, &DeleteOptions{Strategy: DeleteReparent})
+	require.NoError(t, err)
+	assertClosureConsistent(t, db, repo)
+	ancestors, err := svc.GetAncestors(ctx, concurrency.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"技术", "并发"}, folderNames(ancestors))
+	stored, err := repo.FindByID(ctx, channels.ID)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("/%d/%d/%d/", tech.ID, concurrency.ID, channels.ID), stored.Path)
+	assert.Equal(t, 2, stored.Depth)
+
+	// 级联删除后整棵子树恢复
+	_, err = svc.DeleteFolderWithOptions(ctx, life.ID, &DeleteOptions{Strategy: DeleteCascade})
+	require.NoError(t, err)
+	result, err := svc.RestoreFolder(ctx, life.ID, &RestoreOptions{IncludeDescendants: true})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uint{life.ID, travel.ID}, result.RestoredIDs)
+	assertClosureConsistent(t, db, repo)
+
+	// 父节点仍在回收站中时恢复到根节点
+	_, err = svc.DeleteFolderWithOptions(ctx, life.ID, &DeleteOptions{Strategy: DeleteCascade})
+	require.NoError(t, err)
+	_, err = svc.RestoreFolder(ctx, travel.ID, nil)
+	require.NoError(t, err)
+	assertClosureConsistent(t, db, repo)
+	ancestors, err = svc.GetAncestors(ctx, travel.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"旅行"}, folderNames(ancestors))
+
+	// 永久删除后清理闭包记录
+	require.NoError(t, db.Table(testTableName).Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Update("deleted_at", time.Now().Add(-48*time.Hour)).Error)
+	purged, err := svc.PurgeTrash(ctx, 24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+	assertClosureConsistent(t, db, repo)
+}
+
+// TestClosureRepository_RollbackOnFailure 测试事务失败时闭包记录一并回滚
+func TestClosureRepository_RollbackOnFailure(t *testing.T) {
+	db, repo := newClosureTestRepo(t)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	tech := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &tech.ID)
+	mustCreateFolder(t, svc, "并发", &golang.ID)
+	life := mustCreateFolder(t, svc, "生活", nil)
+
+	// 闭包表已在 Update 中移动，更新子孙路径时失败
+	faulty := NewService(&faultyRepository{Repository: repo, failOn: "UpdateChildrenPathAndDepth"})
+	err := faulty.MoveFolder(ctx, golang.ID, &life.ID)
+	assert.ErrorIs(t, err, errInjected)
+	assertClosureConsistent(t, db, repo)
+
+	count, err := svc.CountDescendants(ctx, tech.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+// TestClosureRepository_RebuildClosure 测试从 parent_id 重建闭包表
+func TestClosureRepository_RebuildClosure(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	// 先以物化路径仓储写入数据
+	svc := NewService(NewGormRepository(db, testTableName))
+	tech := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &tech.ID)
+	trashed := mustCreateFolder(t, svc, "草稿", &golang.ID)
+	require.NoError(t, svc.DeleteFolder(ctx, trashed.ID))
+
+	repo := NewClosureRepository(db, testTableName)
+	require.NoError(t, repo.MigrateClosureTable(ctx))
+	require.NoError(t, repo.RebuildClosure(ctx))
+	assertClosureConsistent(t, db, repo)
+
+	// 重复执行结果不变
+	require.NoError(t, repo.RebuildClosure(ctx))
+	assertClosureConsistent(t, db, repo)
+
+	deleted, err := repo.FindDeletedByPath(ctx, tech.Path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"草稿"}, folderNames(deleted))
+}
@@ -4,39 +4,169 @@ import (
 	"context"
 	"errors"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/KOMKZ/go-yogan-domain-folder/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // GormRepository GORM 实现的 Repository
 type GormRepository struct {
-	db        *gorm.DB
-	tableName string
+	db          *gorm.DB
+	tableName   string
+	scopeColumn string      // 作用域列，为空表示不启用作用域
+	scopeValue  interface{} // 固定作用域值，为 nil 时从 context 读取
+}
+
+// GormOption GormRepository 配置项
+type GormOption func(*GormRepository)
+
+// WithScopeColumn 启用作用域隔离，作用域值通过 ContextWithScope 从 context 读取
+// context 中缺少作用域值时所有操作返回 ErrScopeMissing
+func WithScopeColumn(column string) GormOption {
+	return func(r *GormRepository) {
+		r.scopeColumn = column
+	}
+}
+
+// WithFixedScope 启用作用域隔离并固定作用域值
+func WithFixedScope(column string, value interface{}) GormOption {
+	return func(r *GormRepository) {
+		r.scopeColumn = column
+		r.scopeValue = value
+	}
 }
 
 // NewGormRepository 创建 GORM Repository
 // tableName 参数允许不同业务使用不同的表
-func NewGormRepository(db *gorm.DB, tableName string) *GormRepository {
-	return &GormRepository{
+func NewGormRepository(db *gorm.DB, tableName string, opts ...GormOption) *GormRepository {
+	r := &GormRepository{
 		db:        db,
 		tableName: tableName,
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
-// table 返回指定表名的 DB 实例
+// table 返回指定表名的 DB 实例，启用作用域时自动追加作用域条件
 func (r *GormRepository) table(ctx context.Context) *gorm.DB {
-	return r.db.WithContext(ctx).Table(r.tableName)
+	db := r.db.WithContext(ctx).Table(r.tableName)
+	if r.scopeColumn == "" {
+		return db
+	}
+	value, err := r.scope(ctx)
+	if err != nil {
+		_ = db.AddError(err)
+		return db
+	}
+	return db.Where(clause.Eq{Column: clause.Column{Name: r.scopeColumn}, Value: value})
+}
+
+// scope 解析当前作用域值
+func (r *GormRepository) scope(ctx context.Context) (interface{}, error) {
+	if r.scopeValue != nil {
+		return r.scopeValue, nil
+	}
+	value, ok := ScopeFromContext(ctx)
+	if !ok {
+		return nil, ErrScopeMissing
+	}
+	return value, nil
+}
+
+// aggregate 返回绑定模型的 DB 实例，用于 Count/Scan 等聚合查询
+// 不绑定模型时 GORM 不会追加软删除条件，回收站中的记录会被计入
+func (r *GormRepository) aggregate(ctx context.Context) *gorm.DB {
+	return r.table(ctx).Model(&model.Folder{})
+}
+
+// WithTx 在数据库事务中执行 fn
+// 已处于事务中时由 GORM 使用 SavePoint 嵌套
+func (r *GormRepository) WithTx(ctx context.Context, fn func(repo Repository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(r.withDB(tx))
+	})
+}
+
+// withDB 返回使用指定 DB 实例的仓储副本
+func (r *GormRepository) withDB(db *gorm.DB) *GormRepository {
+	clone := *r
+	clone.db = db
+	return &clone
 }
 
-// Create 创建文件夹
+// Create 创建文件夹，启用作用域时在同一条 INSERT 中写入作用域列
 func (r *GormRepository) Create(ctx context.Context, folder *model.Folder) error {
-	return r.table(ctx).Create(folder).Error
+	if r.scopeColumn == "" {
+		return translateError(r.table(ctx).Create(folder).Error)
+	}
+
+	value, err := r.scope(ctx)
+	if err != nil {
+		return err
+	}
+	return translateError(r.table(ctx).
+		Clauses(scopeValues{column: r.scopeColumn, value: value}).
+		Create(folder).Error)
 }
 
+// scopeValues 向 INSERT 的 VALUES 子句追加作用域列
+// 作用域列不在模型中，无法通过字段赋值写入；在构建 SQL 时追加列可保留 GORM 对
+// 默认值、时间戳和 RETURNING 主键回填的处理，也避免先插入 NULL 再回写（作用域列可为 NOT NULL）
+type scopeValues struct {
+	column string
+	value  interface{}
+}
+
+// ModifyStatement 为当前语句的 VALUES 子句设置构建函数
+func (s scopeValues) ModifyStatement(stmt *gorm.Statement) {
+	c := stmt.Clauses["VALUES"]
+	c.Builder = func(c clause.Clause, builder clause.Builder) {
+		if values, ok := c.Expression.(clause.Values); ok {
+			columns := append(append([]clause.Column{}, values.Columns...), clause.Column{Name: s.column})
+			rows := make([][]interface{}, len(values.Values))
+			for i, row := range values.Values {
+				rows[i] = append(append([]interface{}{}, row...), s.value)
+			}
+			c.Expression = clause.Values{Columns: columns, Values: rows}
+		}
+		c.Builder = nil
+		c.Build(builder)
+	}
+	stmt.Clauses["VALUES"] = c
+}
+
+// Build 实现 clause.Expression，本身不输出 SQL
+func (s scopeValues) Build(clause.Builder) {}
+
 // Update 更新文件夹
+// 以 folder.Version 作为期望版本号，行已被修改时返回 ErrVersionConflict，成功后版本号加一
+// 不使用 Save：Save 在未命中行时会退化为 upsert，可能越过作用域写入其他租户的数据
 func (r *GormRepository) Update(ctx context.Context, folder *model.Folder) error {
-	return r.table(ctx).Save(folder).Error
+	return r.updateVersioned(r.table(ctx), folder)
+}
+
+// updateVersioned 按版本号整行更新
+func (r *GormRepository) updateVersioned(db *gorm.DB, folder *model.Folder) error {
+	expected := folder.Version
+	folder.Version = expected + 1
+	result := db.
+		Where("version = ?", expected).
+		Select("*").Omit("created_at").
+		Updates(folder)
+	if result.Error != nil {
+		folder.Version = expected
+		return translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		folder.Version = expected
+		return ErrVersionConflict
+	}
+	return nil
 }
 
 // Delete 删除文件夹（软删除）
@@ -44,6 +174,14 @@ func (r *GormRepository) Delete(ctx context.Context, id uint) error {
 	return r.table(ctx).Delete(&model.Folder{}, id).Error
 }
 
+// DeleteByIDs 批量删除文件夹（软删除）
+func (r *GormRepository) DeleteByIDs(ctx context.Context, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.table(ctx).Delete(&model.Folder{}, ids).Error
+}
+
 // FindByID 根据 ID 查询
 func (r *GormRepository) FindByID(ctx context.Context, id uint) (*model.Folder, error) {
 	var folder model.Folder
@@ -57,6 +195,26 @@ func (r *GormRepository) FindByID(ctx context.Context, id uint) (*model.Folder,
 	return &folder, nil
 }
 
+// UpdatePinyin 更新拼音索引；拼音为名称的派生数据，不递增版本号
+func (r *GormRepository) UpdatePinyin(ctx context.Context, id uint, full, initials string) error {
+	return r.table(ctx).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"pinyin": full, "pinyin_initials": initials}).Error
+}
+
+// FindByIDs 根据 ID 批量查询，按深度排序
+func (r *GormRepository) FindByIDs(ctx context.Context, ids []uint) ([]*model.Folder, error) {
+	if len(ids) == 0 {
+		return []*model.Folder{}, nil
+	}
+	var folders []*model.Folder
+	err := r.table(ctx).
+		Where("id IN ?", ids).
+		Order("depth ASC, id ASC").
+		Find(&folders).Error
+	return folders, err
+}
+
 // FindByParentID 根据父 ID 查询子节点
 func (r *GormRepository) FindByParentID(ctx context.Context, parentID *uint) ([]*model.Folder, error) {
 	var folders []*model.Folder
@@ -66,16 +224,85 @@ func (r *GormRepository) FindByParentID(ctx context.Context, parentID *uint) ([]
 	} else {
 		query = query.Where("parent_id = ?", *parentID)
 	}
-	err := query.Order("sort_order ASC, id ASC").Find(&folders).Error
+	err := query.Order("sort_order ASC, sort_key ASC, id ASC").Find(&folders).Error
 	return folders, err
 }
 
+// FindByParentIDAfter 按同级顺序查询 after 之后的最多 limit 个子节点（键集分页），after 为 nil 时从头开始
+func (r *GormRepository) FindByParentIDAfter(ctx context.Context, parentID *uint, after *ChildCursor, limit int) ([]*model.Folder, error) {
+	var folders []*model.Folder
+	query := r.table(ctx)
+	if parentID == nil {
+		query = query.Where("parent_id IS NULL")
+	} else {
+		query = query.Where("parent_id = ?", *parentID)
+	}
+	if after != nil {
+		query = query.Where(
+			"sort_order > ? OR (sort_order = ? AND (sort_key > ? OR (sort_key = ? AND id > ?)))",
+			after.SortOrder, after.SortOrder, after.SortKey, after.SortKey, after.ID,
+		)
+	}
+	err := query.Order("sort_order ASC, sort_key ASC, id ASC").Limit(limit).Find(&folders).Error
+	return folders, err
+}
+
+// CountByParentID 统计子节点数量
+func (r *GormRepository) CountByParentID(ctx context.Context, parentID *uint) (int64, error) {
+	var count int64
+	query := r.aggregate(ctx)
+	if parentID == nil {
+		query = query.Where("parent_id IS NULL")
+	} else {
+		query = query.Where("parent_id = ?", *parentID)
+	}
+	err := query.Count(&count).Error
+	return count, err
+}
+
+// CountChildrenAtDepth 通过一次分组查询统计路径前缀下深度为 depth 的节点各自的直接子节点数量
+// 按路径和深度筛选子节点，不绑定父节点 ID 列表；没有子节点的节点不出现在结果中
+func (r *GormRepository) CountChildrenAtDepth(ctx context.Context, pathPrefix string, depth int) (map[uint]int, error) {
+	var rows []struct {
+		ParentID uint
+		Count    int
+	}
+	err := r.aggregate(ctx).
+		Select("parent_id, COUNT(*) AS count").
+		Where(pathPrefixCond, pathPrefixPattern(pathPrefix)).
+		Where("depth = ?", depth+1).
+		Group("parent_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		counts[row.ParentID] = row.Count
+	}
+	return counts, nil
+}
+
+// CountDescendants 按路径前缀统计子孙节点数量（不含自身），不加载记录
+func (r *GormRepository) CountDescendants(ctx context.Context, id uint) (int64, error) {
+	folder, err := r.FindByID(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	err = r.aggregate(ctx).
+		Where(pathPrefixCond, pathPrefixPattern(folder.Path)).
+		Where("id != ?", id).
+		Count(&count).Error
+	return count, err
+}
+
 // FindChildren 查询直接子节点
 func (r *GormRepository) FindChildren(ctx context.Context, parentID uint) ([]*model.Folder, error) {
 	var folders []*model.Folder
 	err := r.table(ctx).
 		Where("parent_id = ?", parentID).
-		Order("sort_order ASC, id ASC").
+		Order("sort_order ASC, sort_key ASC, id ASC").
 		Find(&folders).Error
 	return folders, err
 }
@@ -85,17 +312,47 @@ func (r *GormRepository) FindRoots(ctx context.Context) ([]*model.Folder, error)
 	var folders []*model.Folder
 	err := r.table(ctx).
 		Where("parent_id IS NULL").
-		Order("sort_order ASC, id ASC").
+		Order("sort_order ASC, sort_key ASC, id ASC").
 		Find(&folders).Error
 	return folders, err
 }
 
+// FindByNameAndParent 查询父节点下指定名称的子节点
+func (r *GormRepository) FindByNameAndParent(ctx context.Context, name string, parentID *uint) (*model.Folder, error) {
+	var folder model.Folder
+	query := r.table(ctx).Where("name = ?", name)
+	if parentID == nil {
+		query = query.Where("parent_id IS NULL")
+	} else {
+		query = query.Where("parent_id = ?", *parentID)
+	}
+	err := query.Order("id ASC").First(&folder).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &folder, nil
+}
+
 // FindByPath 根据路径前缀查询所有子孙节点
 func (r *GormRepository) FindByPath(ctx context.Context, pathPrefix string) ([]*model.Folder, error) {
 	var folders []*model.Folder
 	err := r.table(ctx).
-		Where("path LIKE ?", pathPrefix+"%").
-		Order("depth ASC, sort_order ASC, id ASC").
+		Where(pathPrefixCond, pathPrefixPattern(pathPrefix)).
+		Order("depth ASC, sort_order ASC, sort_key ASC, id ASC").
+		Find(&folders).Error
+	return folders, err
+}
+
+// FindByPathMaxDepth 根据路径前缀查询深度不超过 maxDepth 的子孙节点
+func (r *GormRepository) FindByPathMaxDepth(ctx context.Context, pathPrefix string, maxDepth int) ([]*model.Folder, error) {
+	var folders []*model.Folder
+	err := r.table(ctx).
+		Where(pathPrefixCond, pathPrefixPattern(pathPrefix)).
+		Where("depth <= ?", maxDepth).
+		Order("depth ASC, sort_order ASC, sort_key ASC, id ASC").
 		Find(&folders).Error
 	return folders, err
 }
@@ -116,11 +373,55 @@ func (r *GormRepository) FindAncestors(ctx context.Context, path string) ([]*mod
 	return folders, err
 }
 
+// FindByNamePath 按名称路径逐级查找文件夹，names 为从根开始的各级名称
+// 一次查询取出各层级同名的候选节点，再在内存中沿父子关系逐级匹配
+func (r *GormRepository) FindByNamePath(ctx context.Context, names []string) (*model.Folder, error) {
+	if len(names) == 0 {
+		return nil, ErrNotFound
+	}
+
+	levels := make([]clause.Expression, 0, len(names))
+	for depth, name := range names {
+		levels = append(levels, clause.And(
+			clause.Eq{Column: clause.Column{Name: "depth"}, Value: depth},
+			clause.Eq{Column: clause.Column{Name: "name"}, Value: name},
+		))
+	}
+
+	var candidates []*model.Folder
+	err := r.table(ctx).
+		Where(clause.Or(levels...)).
+		Order("depth ASC, id ASC").
+		Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var current *model.Folder
+	for depth, name := range names {
+		var next *model.Folder
+		for _, c := range candidates {
+			if c.Depth != depth || c.Name != name {
+				continue
+			}
+			if (current == nil && c.ParentID == nil) || (current != nil && c.ParentID != nil && *c.ParentID == current.ID) {
+				next = c
+				break
+			}
+		}
+		if next == nil {
+			return nil, ErrNotFound
+		}
+		current = next
+	}
+	return current, nil
+}
+
 // FindAll 查询所有文件夹
 func (r *GormRepository) FindAll(ctx context.Context) ([]*model.Folder, error) {
 	var folders []*model.Folder
 	err := r.table(ctx).
-		Order("depth ASC, sort_order ASC, id ASC").
+		Order("depth ASC, sort_order ASC, sort_key ASC, id ASC").
 		Find(&folders).Error
 	return folders, err
 }
@@ -129,13 +430,32 @@ func (r *GormRepository) FindAll(ctx context.Context) ([]*model.Folder, error) {
 func (r *GormRepository) UpdateSortOrder(ctx context.Context, id uint, sortOrder int) error {
 	return r.table(ctx).
 		Where("id = ?", id).
-		Update("sort_order", sortOrder).Error
+		Updates(map[string]interface{}{
+			"sort_order": sortOrder,
+			"version":    gorm.Expr("version + 1"),
+		}).Error
+}
+
+// ShiftSortOrders 将同级下排序号不小于 from 的节点（excludeID 除外）后移一位
+func (r *GormRepository) ShiftSortOrders(ctx context.Context, parentID *uint, from int, excludeID uint) error {
+	query := r.table(ctx)
+	if parentID == nil {
+		query = query.Where("parent_id IS NULL")
+	} else {
+		query = query.Where("parent_id = ?", *parentID)
+	}
+	return query.
+		Where("sort_order >= ? AND id != ?", from, excludeID).
+		Updates(map[string]interface{}{
+			"sort_order": gorm.Expr("sort_order + 1"),
+			"version":    gorm.Expr("version + 1"),
+		}).Error
 }
 
 // FindMaxSortOrder 查询同级下最大排序号
 func (r *GormRepository) FindMaxSortOrder(ctx context.Context, parentID *uint) (int, error) {
 	var maxOrder int
-	query := r.table(ctx).Select("COALESCE(MAX(sort_order), 0)")
+	query := r.aggregate(ctx).Select("COALESCE(MAX(sort_order), 0)")
 	if parentID == nil {
 		query = query.Where("parent_id IS NULL")
 	} else {
@@ -145,32 +465,96 @@ func (r *GormRepository) FindMaxSortOrder(ctx context.Context, parentID *uint) (
 	return maxOrder, err
 }
 
+// UpdateSortKey 更新排序键
+func (r *GormRepository) UpdateSortKey(ctx context.Context, id uint, sortKey string) error {
+	return r.table(ctx).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"sort_key": sortKey,
+			"version":  gorm.Expr("version + 1"),
+		}).Error
+}
+
+// FindMaxSortKey 查询同级下最大排序键，没有兄弟节点时返回空串
+func (r *GormRepository) FindMaxSortKey(ctx context.Context, parentID *uint) (string, error) {
+	var maxKey string
+	query := r.aggregate(ctx).Select("COALESCE(MAX(sort_key), '')")
+	if parentID == nil {
+		query = query.Where("parent_id IS NULL")
+	} else {
+		query = query.Where("parent_id = ?", *parentID)
+	}
+	err := query.Scan(&maxKey).Error
+	return maxKey, err
+}
+
 // UpdatePathAndDepth 更新单个节点的路径和深度
 func (r *GormRepository) UpdatePathAndDepth(ctx context.Context, id uint, path string, depth int) error {
 	return r.table(ctx).
 		Where("id = ?", id).
 		Updates(map[string]interface{}{
-			"path":  path,
-			"depth": depth,
+			"path":    path,
+			"depth":   depth,
+			"version": gorm.Expr("version + 1"),
 		}).Error
 }
 
 // UpdateChildrenPathAndDepth 批量更新子孙节点的路径和深度
 func (r *GormRepository) UpdateChildrenPathAndDepth(ctx context.Context, oldPathPrefix, newPathPrefix string, depthDiff int) error {
-	// 使用 SQL 表达式更新
+	// 只替换开头的前缀，REPLACE 会替换路径中所有出现的位置
 	return r.table(ctx).
-		Where("path LIKE ?", oldPathPrefix+"%").
+		Where(pathPrefixCond, pathPrefixPattern(oldPathPrefix)).
 		Where("path != ?", oldPathPrefix). // 排除自身
 		Updates(map[string]interface{}{
-			"path":  gorm.Expr("REPLACE(path, ?, ?)", oldPathPrefix, newPathPrefix),
-			"depth": gorm.Expr("depth + ?", depthDiff),
+			"path":    r.rewritePathPrefix(oldPathPrefix, newPathPrefix),
+			"depth":   gorm.Expr("depth + ?", depthDiff),
+			"version": gorm.Expr("version + 1"),
 		}).Error
 }
 
+// SearchByName 按名称不区分大小写地搜索，结果按深度和同级顺序排列
+// query.Pinyin 为 true 时同时匹配拼音全拼与首字母
+func (r *GormRepository) SearchByName(ctx context.Context, query *NameQuery) ([]*model.Folder, int64, error) {
+	like := func(keyword string) string {
+		pattern := escapeLike(keyword) + "%"
+		if query.Match != MatchPrefix {
+			pattern = "%" + pattern
+		}
+		return pattern
+	}
+	escape := " ESCAPE '" + likeEscape + "'"
+
+	cond := r.aggregate(ctx)
+	nameCond := "LOWER(name) LIKE ?" + escape
+	if query.Pinyin {
+		// 拼音列已是小写且不含空白
+		spelled := like(strings.Join(strings.Fields(strings.ToLower(query.Keyword)), ""))
+		cond = cond.Where("("+nameCond+" OR pinyin LIKE ?"+escape+" OR pinyin_initials LIKE ?"+escape+")",
+			like(strings.ToLower(query.Keyword)), spelled, spelled)
+	} else {
+		cond = cond.Where(nameCond, like(strings.ToLower(query.Keyword)))
+	}
+	// Session 使条件可在 Count 和 Find 之间安全复用
+	cond = cond.Session(&gorm.Session{})
+
+	var total int64
+	if err := cond.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var folders []*model.Folder
+	err := cond.
+		Order("depth ASC, sort_order ASC, sort_key ASC, id ASC").
+		Offset(query.Offset).
+		Limit(query.Limit).
+		Find(&folders).Error
+	return folders, total, err
+}
+
 // ExistsByNameAndParent 检查同级下是否存在相同名称
 func (r *GormRepository) ExistsByNameAndParent(ctx context.Context, name string, parentID *uint, excludeID *uint) (bool, error) {
 	var count int64
-	query := r.table(ctx).Where("name = ?", name)
+	query := r.aggregate(ctx).Where("name = ?", name)
 	if parentID == nil {
 		query = query.Where("parent_id IS NULL")
 	} else {
@@ -186,10 +570,130 @@ func (r *GormRepository) ExistsByNameAndParent(ctx context.Context, name string,
 // HasChildren 检查是否有子节点
 func (r *GormRepository) HasChildren(ctx context.Context, id uint) (bool, error) {
 	var count int64
-	err := r.table(ctx).Where("parent_id = ?", id).Count(&count).Error
+	err := r.aggregate(ctx).Where("parent_id = ?", id).Count(&count).Error
 	return count > 0, err
 }
 
+// FindDeleted 查询回收站中的所有文件夹，最近删除的在前
+func (r *GormRepository) FindDeleted(ctx context.Context) ([]*model.Folder, error) {
+	var folders []*model.Folder
+	err := r.table(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC, depth ASC, id ASC").
+		Find(&folders).Error
+	return folders, err
+}
+
+// FindDeletedByID 根据 ID 查询已删除的文件夹
+func (r *GormRepository) FindDeletedByID(ctx context.Context, id uint) (*model.Folder, error) {
+	var folder model.Folder
+	err := r.table(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL").
+		First(&folder, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &folder, nil
+}
+
+// FindDeletedByPath 根据路径前缀查询已删除的子孙节点（包含自身）
+func (r *GormRepository) FindDeletedByPath(ctx context.Context, pathPrefix string) ([]*model.Folder, error) {
+	var folders []*model.Folder
+	err := r.table(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Where(pathPrefixCond, pathPrefixPattern(pathPrefix)).
+		Order("depth ASC, sort_order ASC, sort_key ASC, id ASC").
+		Find(&folders).Error
+	return folders, err
+}
+
+// FindByIDsUnscoped 批量查询文件夹，包含已删除的记录
+func (r *GormRepository) FindByIDsUnscoped(ctx context.Context, ids []uint) ([]*model.Folder, error) {
+	if len(ids) == 0 {
+		return []*model.Folder{}, nil
+	}
+	var folders []*model.Folder
+	err := r.table(ctx).Unscoped().
+		Where("id IN ?", ids).
+		Order("depth ASC, id ASC").
+		Find(&folders).Error
+	return folders, err
+}
+
+// Restore 恢复单个已删除的文件夹，并同时写入 folder 的其余字段（如新的名称、父节点）
+// 与 Update 一样按版本号校验
+func (r *GormRepository) Restore(ctx context.Context, folder *model.Folder) error {
+	folder.DeletedAt = gorm.DeletedAt{}
+	return r.updateVersioned(r.table(ctx).Unscoped().Where("deleted_at IS NOT NULL"), folder)
+}
+
+// RestoreByIDs 批量恢复已删除的文件夹，保留其原有字段
+func (r *GormRepository) RestoreByIDs(ctx context.Context, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return translateError(r.table(ctx).Unscoped().
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"version":    gorm.Expr("version + 1"),
+		}).Error)
+}
+
+// PurgeDeletedBefore 永久删除在 before 之前进入回收站的文件夹，返回删除条数
+func (r *GormRepository) PurgeDeletedBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.table(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Delete(&model.Folder{})
+	return result.RowsAffected, result.Error
+}
+
+// storedParentID 读取数据库中的父节点 ID（包括回收站中的记录），记录不存在时返回 nil
+// 供维护闭包表、嵌套集合等附加结构的实现判断父节点是否变化
+func (r *GormRepository) storedParentID(ctx context.Context, id uint) (*uint, error) {
+	var folders []*model.Folder
+	if err := r.table(ctx).Unscoped().Select("id", "parent_id").Where("id = ?", id).Limit(1).Find(&folders).Error; err != nil {
+		return nil, err
+	}
+	if len(folders) == 0 {
+		return nil, nil
+	}
+	return folders[0].ParentID, nil
+}
+
+// likeEscape LIKE 转义符；不使用反斜杠，避免 MySQL 字符串字面量再次转义
+const likeEscape = "!"
+
+// escapeLike 转义 LIKE 模式中的通配符，配合 ESCAPE '!' 使用
+func escapeLike(s string) string {
+	return strings.NewReplacer(likeEscape, likeEscape+likeEscape, "%", likeEscape+"%", "_", likeEscape+"_").Replace(s)
+}
+
+// pathPrefixCond 按路径前缀匹配的条件，配合 pathPrefixPattern 使用
+const pathPrefixCond = "path LIKE ? ESCAPE '" + likeEscape + "'"
+
+// pathPrefixPattern 返回匹配以 prefix 开头的路径的 LIKE 模式
+func pathPrefixPattern(prefix string) string {
+	return escapeLike(prefix) + "%"
+}
+
+// rewritePathPrefix 返回将 path 开头的 oldPrefix 替换为 newPrefix 的 SQL 表达式
+// 调用方需保证 path 以 oldPrefix 开头
+func (r *GormRepository) rewritePathPrefix(oldPrefix, newPrefix string) clause.Expr {
+	start := utf8.RuneCountInString(oldPrefix) + 1
+	switch r.db.Dialector.Name() {
+	case "mysql":
+		return gorm.Expr("CONCAT(?, SUBSTRING(path, ?))", newPrefix, start)
+	case "sqlserver":
+		return gorm.Expr("? + SUBSTRING(path, ?, LEN(path))", newPrefix, start)
+	default: // sqlite、postgres
+		return gorm.Expr("? || SUBSTR(path, ?)", newPrefix, start)
+	}
+}
+
 // parsePathIDs 解析路径中的 ID 列表
 // 路径格式："/1/3/5/" -> [1, 3, 5]
 func parsePathIDs(path string) []uint {
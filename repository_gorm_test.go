@@ -1,11 +1,62 @@
 package folder
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
 	"testing"
 
+	"github.com/KOMKZ/go-yogan-domain-folder/model"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 )
 
+const testTableName = "test_folders"
+
+// newTestDB 创建基于临时 SQLite 文件的数据库并建表
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "folder.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Table(testTableName).AutoMigrate(&model.Folder{}))
+	return db
+}
+
+// errInjected 注入的故障
+var errInjected = errors.New("injected failure")
+
+// faultyRepository 在指定方法上注入故障的仓储包装，事务内同样生效
+type faultyRepository struct {
+	Repository
+	failOn string
+}
+
+func (f *faultyRepository) WithTx(ctx context.Context, fn func(repo Repository) error) error {
+	return f.Repository.WithTx(ctx, func(repo Repository) error {
+		return fn(&faultyRepository{Repository: repo, failOn: f.failOn})
+	})
+}
+
+func (f *faultyRepository) Update(ctx context.Context, folder *model.Folder) error {
+	if f.failOn == "Update" {
+		return errInjected
+	}
+	return f.Repository.Update(ctx, folder)
+}
+
+func (f *faultyRepository) UpdateChildrenPathAndDepth(ctx context.Context, oldPathPrefix, newPathPrefix string, depthDiff int) error {
+	if f.failOn == "UpdateChildrenPathAndDepth" {
+		return errInjected
+	}
+	return f.Repository.UpdateChildrenPathAndDepth(ctx, oldPathPrefix, newPathPrefix, depthDiff)
+}
+
 // TestParsePathIDs 测试路径解析
 func TestParsePathIDs(t *testing.T) {
 	tests := []struct {
@@ -68,3 +119,354 @@ func TestNewGormRepository(t *testing.T) {
 	assert.NotNil(t, repo)
 	assert.Equal(t, "test_folders", repo.tableName)
 }
+
+// TestGormRepository_WithTx_Commit 测试事务提交
+func TestGormRepository_WithTx_Commit(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	ctx := context.Background()
+
+	err := repo.WithTx(ctx, func(tx Repository) error {
+		return tx.Create(ctx, &model.Folder{Name: "技术文章", Path: "/"})
+	})
+	require.NoError(t, err)
+
+	all, err := repo.FindAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 1)
+}
+
+// TestGormRepository_WithTx_Rollback 测试事务回滚
+func TestGormRepository_WithTx_Rollback(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	ctx := context.Background()
+
+	err := repo.WithTx(ctx, func(tx Repository) error {
+		if err := tx.Create(ctx, &model.Folder{Name: "技术文章", Path: "/"}); err != nil {
+			return err
+		}
+		return errInjected
+	})
+	assert.ErrorIs(t, err, errInjected)
+
+	all, err := repo.FindAll(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, all)
+}
+
+// TestService_CreateFolder_RollbackPersisted 测试创建时回写 path 失败不会留下 path 为 "/" 的记录
+func TestService_CreateFolder_RollbackPersisted(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(&faultyRepository{Repository: repo, failOn: "Update"})
+	ctx := context.Background()
+
+	_, err := svc.CreateFolder(ctx, &CreateFolderInput{Name: "技术文章"})
+	assert.ErrorIs(t, err, errInjected)
+
+	all, err := repo.FindAll(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, all)
+}
+
+// TestService_MoveFolder_RollbackPersisted 测试移动时子孙更新失败整体回滚
+func TestService_MoveFolder_RollbackPersisted(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	ctx := context.Background()
+
+	svc := NewService(repo)
+	a, err := svc.CreateFolder(ctx, &CreateFolderInput{Name: "A"})
+	require.NoError(t, err)
+	b, err := svc.CreateFolder(ctx, &CreateFolderInput{Name: "B"})
+	require.NoError(t, err)
+	child, err := svc.CreateFolder(ctx, &CreateFolderInput{Name: "B1", ParentID: &b.ID})
+	require.NoError(t, err)
+
+	faulty := NewService(&faultyRepository{Repository: repo, failOn: "UpdateChildrenPathAndDepth"})
+	err = faulty.MoveFolder(ctx, b.ID, &a.ID)
+	assert.ErrorIs(t, err, errInjected)
+
+	moved, err := repo.FindByID(ctx, b.ID)
+	require.NoError(t, err)
+	assert.Nil(t, moved.ParentID)
+	assert.Equal(t, b.Path, moved.Path)
+	assert.Equal(t, 0, moved.Depth)
+
+	kept, err := repo.FindByID(ctx, child.ID)
+	require.NoError(t, err)
+	assert.Equal(t, child.Path, kept.Path)
+}
+
+// scopedFolder 带 NOT NULL 作用域列的测试表结构
+type scopedFolder struct {
+	model.Folder
+	TenantID string `gorm:"size:64;not null"`
+}
+
+// newScopedTestDB 创建带 tenant_id 作用域列（NOT NULL）的测试数据库
+func newScopedTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "folder.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Table(testTableName).AutoMigrate(&scopedFolder{}))
+	return db
+}
+
+// TestGormRepository_Scope_Isolation 测试作用域隔离
+func TestGormRepository_Scope_Isolation(t *testing.T) {
+	db := newScopedTestDB(t)
+	repo := NewGormRepository(db, testTableName, WithScopeColumn("tenant_id"))
+	svc := NewService(repo)
+	ctxA := ContextWithScope(context.Background(), "a")
+	ctxB := ContextWithScope(context.Background(), "b")
+
+	rootA, err := svc.CreateFolder(ctxA, &CreateFolderInput{Name: "技术"})
+	require.NoError(t, err)
+	_, err = svc.CreateFolder(ctxA, &CreateFolderInput{Name: "Go", ParentID: &rootA.ID})
+	require.NoError(t, err)
+
+	// 不同作用域下允许同名，排序号独立
+	rootB, err := svc.CreateFolder(ctxB, &CreateFolderInput{Name: "技术"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, rootB.SortOrder)
+
+	// 创建时写入作用域列
+	var tenant string
+	require.NoError(t, db.Table(testTableName).Select("tenant_id").Where("id = ?", rootB.ID).Scan(&tenant).Error)
+	assert.Equal(t, "b", tenant)
+
+	roots, err := repo.FindRoots(ctxB)
+	require.NoError(t, err)
+	require.Len(t, roots, 1)
+	assert.Equal(t, rootB.ID, roots[0].ID)
+
+	all, err := repo.FindAll(ctxA)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	descendants, err := repo.FindByPath(ctxB, rootA.Path)
+	require.NoError(t, err)
+	assert.Empty(t, descendants)
+
+	_, err = repo.FindByID(ctxB, rootA.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// TestGormRepository_Scope_CrossScopeMoveRejected 测试禁止跨作用域移动
+func TestGormRepository_Scope_CrossScopeMoveRejected(t *testing.T) {
+	repo := NewGormRepository(newScopedTestDB(t), testTableName, WithScopeColumn("tenant_id"))
+	svc := NewService(repo)
+	ctxA := ContextWithScope(context.Background(), "a")
+	ctxB := ContextWithScope(context.Background(), "b")
+
+	folderA, err := svc.CreateFolder(ctxA, &CreateFolderInput{Name: "A"})
+	require.NoError(t, err)
+	folderB, err := svc.CreateFolder(ctxB, &CreateFolderInput{Name: "B"})
+	require.NoError(t, err)
+
+	// 目标父节点属于其他作用域
+	err = svc.MoveFolder(ctxA, folderA.ID, &folderB.ID)
+	assert.ErrorIs(t, err, ErrParentNotFound)
+
+	// 被移动节点属于其他作用域
+	err = svc.MoveFolder(ctxB, folderA.ID, &folderB.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// TestGormRepository_Scope_CreateNotNull 测试作用域列为 NOT NULL 时在插入语句中直接写入作用域
+func TestGormRepository_Scope_CreateNotNull(t *testing.T) {
+	db := newScopedTestDB(t)
+	repo := NewGormRepository(db, testTableName, WithScopeColumn("tenant_id"))
+	ctx := ContextWithScope(context.Background(), "a")
+
+	folder := &model.Folder{Name: "技术", Path: "/"}
+	require.NoError(t, repo.Create(ctx, folder))
+	assert.NotZero(t, folder.ID)
+	assert.False(t, folder.CreatedAt.IsZero())
+
+	var stored scopedFolder
+	require.NoError(t, db.Table(testTableName).First(&stored, folder.ID).Error)
+	assert.Equal(t, "a", stored.TenantID)
+	assert.Equal(t, "技术", stored.Name)
+}
+
+// TestGormRepository_Scope_Missing 测试 context 缺少作用域
+func TestGormRepository_Scope_Missing(t *testing.T) {
+	repo := NewGormRepository(newScopedTestDB(t), testTableName, WithScopeColumn("tenant_id"))
+	ctx := context.Background()
+
+	err := repo.Create(ctx, &model.Folder{Name: "技术", Path: "/"})
+	assert.ErrorIs(t, err, ErrScopeMissing)
+
+	_, err = repo.FindRoots(ctx)
+	assert.ErrorIs(t, err, ErrScopeMissing)
+
+	_, err = repo.ExistsByNameAndParent(ctx, "技术", nil, nil)
+	assert.ErrorIs(t, err, ErrScopeMissing)
+}
+
+// TestGormRepository_FixedScope 测试固定作用域
+func TestGormRepository_FixedScope(t *testing.T) {
+	db := newScopedTestDB(t)
+	repoA := NewGormRepository(db, testTableName, WithFixedScope("tenant_id", "a"))
+	repoB := NewGormRepository(db, testTableName, WithFixedScope("tenant_id", "b"))
+	ctx := context.Background()
+
+	_, err := NewService(repoA).CreateFolder(ctx, &CreateFolderInput{Name: "技术"})
+	require.NoError(t, err)
+
+	maxOrder, err := repoB.FindMaxSortOrder(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, maxOrder)
+
+	exists, err := repoB.ExistsByNameAndParent(ctx, "技术", nil, nil)
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+// TestGormRepository_Update_VersionConflict 测试并发修改同一行时后写者失败
+func TestGormRepository_Update_VersionConflict(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	ctx := context.Background()
+
+	f, err := NewService(repo).CreateFolder(ctx, &CreateFolderInput{Name: "技术"})
+	require.NoError(t, err)
+
+	first, err := repo.FindByID(ctx, f.ID)
+	require.NoError(t, err)
+	second, err := repo.FindByID(ctx, f.ID)
+	require.NoError(t, err)
+
+	first.Name = "技术文章"
+	require.NoError(t, repo.Update(ctx, first))
+	assert.Equal(t, second.Version+1, first.Version)
+
+	second.Name = "技术博客"
+	err = repo.Update(ctx, second)
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	assert.Equal(t, first.Version-1, second.Version)
+
+	stored, err := repo.FindByID(ctx, f.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "技术文章", stored.Name)
+	assert.Equal(t, first.Version, stored.Version)
+}
+
+// TestGormRepository_BatchUpdate_BumpsVersion 测试批量更新路径时递增版本号
+func TestGormRepository_BatchUpdate_BumpsVersion(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	a := mustCreateFolder(t, svc, "A", nil)
+	b := mustCreateFolder(t, svc, "B", nil)
+	child := mustCreateFolder(t, svc, "B1", &b.ID)
+
+	require.NoError(t, svc.MoveFolder(ctx, b.ID, &a.ID))
+
+	// 移动前读取的子节点已过期，重命名应失败而不是写回旧路径
+	child.Name = "B2"
+	err := repo.Update(ctx, child)
+	assert.ErrorIs(t, err, ErrVersionConflict)
+
+	stored, err := repo.FindByID(ctx, child.ID)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("/%d/%d/%d/", a.ID, b.ID, child.ID), stored.Path)
+}
+
+// TestService_UpdateFolder_StaleVersion 测试服务层使用过期版本号更新
+func TestService_UpdateFolder_StaleVersion(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	f := mustCreateFolder(t, svc, "技术", nil)
+	version := f.Version
+
+	_, err := svc.UpdateFolder(ctx, &UpdateFolderInput{ID: f.ID, Name: "技术文章", Version: &version})
+	require.NoError(t, err)
+
+	_, err = svc.UpdateFolder(ctx, &UpdateFolderInput{ID: f.ID, Name: "技术博客", Version: &version})
+	assert.ErrorIs(t, err, ErrVersionConflict)
+}
+
+// insertWithPath 直接写入指定路径的记录，用于构造 ID 无法自然产生的路径
+func insertWithPath(t *testing.T, repo *GormRepository, name, path string, depth int) *model.Folder {
+	t.Helper()
+	f := &model.Folder{Name: name, Path: path, Depth: depth}
+	require.NoError(t, repo.Create(context.Background(), f))
+	return f
+}
+
+// pathsOf 按 ID 返回记录的路径
+func pathsOf(t *testing.T, repo *GormRepository, folders ...*model.Folder) []string {
+	t.Helper()
+	paths := make([]string, 0, len(folders))
+	for _, f := range folders {
+		stored, err := repo.FindByID(context.Background(), f.ID)
+		require.NoError(t, err)
+		paths = append(paths, stored.Path)
+	}
+	return paths
+}
+
+// TestGormRepository_UpdateChildrenPathAndDepth_PrefixOnly 测试只替换开头的前缀
+func TestGormRepository_UpdateChildrenPathAndDepth_PrefixOnly(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	ctx := context.Background()
+
+	self := insertWithPath(t, repo, "self", "/1/", 0)
+	child := insertWithPath(t, repo, "child", "/1/2/", 1)
+	repeated := insertWithPath(t, repo, "repeated", "/1/2/1/", 2)
+	nested := insertWithPath(t, repo, "nested", "/1/2/1/2/1/", 4)
+	other := insertWithPath(t, repo, "other", "/3/1/", 1)
+
+	require.NoError(t, repo.UpdateChildrenPathAndDepth(ctx, "/1/", "/9/1/", 1))
+
+	assert.Equal(t,
+		[]string{"/1/", "/9/1/2/", "/9/1/2/1/", "/9/1/2/1/2/1/", "/3/1/"},
+		pathsOf(t, repo, self, child, repeated, nested, other))
+
+	stored, err := repo.FindByID(ctx, nested.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 5, stored.Depth)
+
+	// 移向更浅的位置
+	require.NoError(t, repo.UpdateChildrenPathAndDepth(ctx, "/9/1/", "/1/", -1))
+	assert.Equal(t,
+		[]string{"/1/2/", "/1/2/1/", "/1/2/1/2/1/"},
+		pathsOf(t, repo, child, repeated, nested))
+}
+
+// TestGormRepository_PathPrefix_EscapesWildcards 测试路径前缀中的通配符按字面匹配
+func TestGormRepository_PathPrefix_EscapesWildcards(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	ctx := context.Background()
+
+	insertWithPath(t, repo, "underscore", "/a_b/", 0)
+	literal := insertWithPath(t, repo, "literal", "/a_b/c/", 1)
+	lookalike := insertWithPath(t, repo, "lookalike", "/axb/c/", 1)
+	insertWithPath(t, repo, "percent", "/a%/", 0)
+	percentChild := insertWithPath(t, repo, "percentChild", "/a%/d/", 1)
+	insertWithPath(t, repo, "plain", "/abc/d/", 1)
+
+	folders, err := repo.FindByPath(ctx, "/a_b/")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"underscore", "literal"}, folderNames(folders))
+
+	folders, err = repo.FindByPathMaxDepth(ctx, "/a%/", 1)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"percent", "percentChild"}, folderNames(folders))
+
+	require.NoError(t, repo.UpdateChildrenPathAndDepth(ctx, "/a_b/", "/x/a_b/", 1))
+	assert.Equal(t, []string{"/x/a_b/c/", "/axb/c/", "/a%/d/"}, pathsOf(t, repo, literal, lookalike, percentChild))
+}
+
+// folderNames 返回文件夹名称列表
+func folderNames(folders []*model.Folder) []string {
+	names := make([]string, 0, len(folders))
+	for _, f := range folders {
+		names = append(names, f.Name)
+	}
+	return names
+}
@@ -0,0 +1,151 @@
+package folder
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/KOMKZ/go-yogan-domain-folder/model"
+	"gorm.io/gorm"
+)
+
+// ltreeColumn ltree 层级列名
+const ltreeColumn = "path_ltree"
+
+// LtreeRepository 基于 PostgreSQL ltree 的 Repository 实现
+// 层级保存在由 path 生成的 ltree 列中（"/1/3/5/" -> 1.3.5），子树与祖先查询使用 <@、@> 运算符和 GiST 索引，
+// 不再依赖 path LIKE 前缀匹配；model.Folder.Path 仍为原有的字符串形式
+type LtreeRepository struct {
+	*GormRepository
+}
+
+// NewLtreeRepository 创建 ltree Repository，仅支持 PostgreSQL
+// 使用前需通过 MigrateLtree 或 LtreeDDL 创建 ltree 列和索引
+func NewLtreeRepository(db *gorm.DB, tableName string, opts ...GormOption) *LtreeRepository {
+	return &LtreeRepository{GormRepository: NewGormRepository(db, tableName, opts...)}
+}
+
+// LtreeIndexName ltree 列的 GiST 索引名
+func (r *LtreeRepository) LtreeIndexName() string {
+	return fmt.Sprintf("idx_%s_%s", r.tableName, ltreeColumn)
+}
+
+// LtreeDDL 返回启用 ltree 扩展、创建 ltree 生成列及 GiST 索引的 DDL
+// ltree 列为 STORED 生成列，path 更新时由数据库自动维护（需 PostgreSQL 12+）
+func (r *LtreeRepository) LtreeDDL() ([]string, error) {
+	if r.db.Dialector.Name() != "postgres" {
+		return nil, fmt.Errorf("folder: ltree not supported for dialect %q", r.db.Dialector.Name())
+	}
+	quote := func(name string) string {
+		var b strings.Builder
+		r.db.Dialector.QuoteTo(&b, name)
+		return b.String()
+	}
+	return []string{
+		"CREATE EXTENSION IF NOT EXISTS ltree",
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s ltree GENERATED ALWAYS AS (text2ltree(replace(btrim(path, '/'), '/', '.'))) STORED",
+			quote(r.tableName), quote(ltreeColumn)),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s USING GIST (%s)",
+			quote(r.LtreeIndexName()), quote(r.tableName), quote(ltreeColumn)),
+	}, nil
+}
+
+// MigrateLtree 启用 ltree 扩展并创建 ltree 列和索引，已存在时跳过
+func (r *LtreeRepository) MigrateLtree(ctx context.Context) error {
+	statements, err := r.LtreeDDL()
+	if err != nil {
+		return err
+	}
+	db := r.db.WithContext(ctx)
+	for _, ddl := range statements {
+		if err := db.Exec(ddl).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pathToLtree 将物化路径转换为 ltree 文本："/1/3/5/" -> "1.3.5"
+func pathToLtree(path string) string {
+	return strings.ReplaceAll(strings.Trim(path, "/"), "/", ".")
+}
+
+// WithTx 在数据库事务中执行 fn
+func (r *LtreeRepository) WithTx(ctx context.Context, fn func(repo Repository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&LtreeRepository{GormRepository: r.GormRepository.withDB(tx)})
+	})
+}
+
+// FindByPath 查询路径下的子树（包含自身）
+func (r *LtreeRepository) FindByPath(ctx context.Context, pathPrefix string) ([]*model.Folder, error) {
+	var folders []*model.Folder
+	err := r.table(ctx).
+		Where(ltreeColumn+" <@ CAST(? AS ltree)", pathToLtree(pathPrefix)).
+		Order("depth ASC, sort_order ASC, sort_key ASC, id ASC").
+		Find(&folders).Error
+	return folders, err
+}
+
+// FindByPathMaxDepth 查询路径下深度不超过 maxDepth 的节点
+func (r *LtreeRepository) FindByPathMaxDepth(ctx context.Context, pathPrefix string, maxDepth int) ([]*model.Folder, error) {
+	var folders []*model.Folder
+	err := r.table(ctx).
+		Where(ltreeColumn+" <@ CAST(? AS ltree)", pathToLtree(pathPrefix)).
+		Where("depth <= ?", maxDepth).
+		Order("depth ASC, sort_order ASC, sort_key ASC, id ASC").
+		Find(&folders).Error
+	return folders, err
+}
+
+// FindAncestors 查询路径上的所有祖先（包含自身），从根开始排列
+func (r *LtreeRepository) FindAncestors(ctx context.Context, path string) ([]*model.Folder, error) {
+	label := pathToLtree(path)
+	if label == "" {
+		return []*model.Folder{}, nil
+	}
+	var folders []*model.Folder
+	err := r.table(ctx).
+		Where(ltreeColumn+" @> CAST(? AS ltree)", label).
+		Where("nlevel(" + ltreeColumn + ") > 0"). // 创建中尚未写入自身 ID 的临时路径
+		Order("depth ASC").
+		Find(&folders).Error
+	return folders, err
+}
+
+// CountDescendants 统计子孙节点数量（不含自身）
+func (r *LtreeRepository) CountDescendants(ctx context.Context, id uint) (int64, error) {
+	folder, err := r.FindByID(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	err = r.aggregate(ctx).
+		Where(ltreeColumn+" <@ CAST(? AS ltree)", pathToLtree(folder.Path)).
+		Where("id != ?", id).
+		Count(&count).Error
+	return count, err
+}
+
+// FindDeletedByPath 查询路径下已删除的子孙节点（包含自身）
+func (r *LtreeRepository) FindDeletedByPath(ctx context.Context, pathPrefix string) ([]*model.Folder, error) {
+	var folders []*model.Folder
+	err := r.table(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Where(ltreeColumn+" <@ CAST(? AS ltree)", pathToLtree(pathPrefix)).
+		Order("depth ASC, sort_order ASC, sort_key ASC, id ASC").
+		Find(&folders).Error
+	return folders, err
+}
+
+// UpdateChildrenPathAndDepth 批量更新子孙节点的 path 和 depth，ltree 列随 path 自动更新
+func (r *LtreeRepository) UpdateChildrenPathAndDepth(ctx context.Context, oldPathPrefix, newPathPrefix string, depthDiff int) error {
+	return r.table(ctx).
+		Where(ltreeColumn+" <@ CAST(? AS ltree)", pathToLtree(oldPathPrefix)).
+		Where("path != ?", oldPathPrefix). // 排除自身
+		Updates(map[string]interface{}{
+			"path":    r.rewritePathPrefix(oldPathPrefix, newPathPrefix),
+			"depth":   gorm.Expr("depth + ?", depthDiff),
+			"version": gorm.Expr("version + 1"),
+		}).Error
+}
@@ -0,0 +1,215 @@
+package folder
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/KOMKZ/go-yogan-domain-folder/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// ltreeTestDSNEnv 集成测试使用的 PostgreSQL DSN 环境变量，未设置时跳过集成测试
+const ltreeTestDSNEnv = "FOLDER_TEST_POSTGRES_DSN"
+
+// sqlRecorder 记录执行的 SQL
+type sqlRecorder struct {
+	logger.Interface
+	statements []string
+}
+
+func (l *sqlRecorder) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	sql, _ := fc()
+	l.statements = append(l.statements, sql)
+}
+
+// newLtreeDryRunRepo 创建只生成 SQL、不连接数据库的 ltree Repository（PostgreSQL 方言）
+func newLtreeDryRunRepo(t *testing.T) (*LtreeRepository, *sqlRecorder) {
+	t.Helper()
+	recorder := &sqlRecorder{Interface: logger.Default.LogMode(logger.Silent)}
+	db, err := gorm.Open(postgres.Open("host=127.0.0.1 user=folder dbname=folder sslmode=disable"), &gorm.Config{
+		Logger:                 recorder,
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+	return NewLtreeRepository(db, testTableName), recorder
+}
+
+// newLtreePostgresRepo 连接 ltreeTestDSNEnv 指定的 PostgreSQL，创建临时表并执行 MigrateLtree
+func newLtreePostgresRepo(t *testing.T) (*LtreeRepository, *gorm.DB) {
+	t.Helper()
+	dsn := os.Getenv(ltreeTestDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s not set, skipping PostgreSQL integration test", ltreeTestDSNEnv)
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	table := fmt.Sprintf("test_folders_ltree_%d", time.Now().UnixNano())
+	require.NoError(t, db.Table(table).AutoMigrate(&model.Folder{}))
+	t.Cleanup(func() {
+		_ = db.Migrator().DropTable(table)
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+
+	repo := NewLtreeRepository(db, table)
+	require.NoError(t, repo.MigrateLtree(context.Background()))
+	return repo, db
+}
+
+// TestPathToLtree 测试物化路径转换为 ltree
+func TestPathToLtree(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/1/3/5/", "1.3.5"},
+		{"/1/", "1"},
+		{"/", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, pathToLtree(tt.path), tt.path)
+	}
+}
+
+// TestLtreeRepository_LtreeDDL 测试 ltree DDL
+func TestLtreeRepository_LtreeDDL(t *testing.T) {
+	repo, _ := newLtreeDryRunRepo(t)
+	statements, err := repo.LtreeDDL()
+	require.NoError(t, err)
+	require.Len(t, statements, 3)
+	assert.Equal(t, "CREATE EXTENSION IF NOT EXISTS ltree", statements[0])
+	assert.Equal(t, `ALTER TABLE "test_folders" ADD COLUMN IF NOT EXISTS "path_ltree" ltree `+
+		`GENERATED ALWAYS AS (text2ltree(replace(btrim(path, '/'), '/', '.'))) STORED`, statements[1])
+	assert.Equal(t, `CREATE INDEX IF NOT EXISTS "`+repo.LtreeIndexName()+`" ON "test_folders" USING GIST ("path_ltree")`, statements[2])
+
+	// 其他方言不支持
+	_, err = NewLtreeRepository(newTestDB(t), testTableName).LtreeDDL()
+	assert.Error(t, err)
+	assert.Error(t, NewLtreeRepository(newTestDB(t), testTableName).MigrateLtree(context.Background()))
+}
+
+// TestLtreeRepository_QueriesUseLtree 测试子树与祖先查询使用 ltree 运算符而不是 LIKE
+func TestLtreeRepository_QueriesUseLtree(t *testing.T) {
+	repo, recorder := newLtreeDryRunRepo(t)
+	ctx := context.Background()
+
+	_, err := repo.FindByPath(ctx, "/1/3/")
+	require.NoError(t, err)
+	_, err = repo.FindByPathMaxDepth(ctx, "/1/3/", 4)
+	require.NoError(t, err)
+	_, err = repo.FindAncestors(ctx, "/1/3/5/")
+	require.NoError(t, err)
+	_, err = repo.FindDeletedByPath(ctx, "/1/3/")
+	require.NoError(t, err)
+	require.NoError(t, repo.UpdateChildrenPathAndDepth(ctx, "/1/3/", "/2/3/", 0))
+
+	require.Len(t, recorder.statements, 5)
+	for _, sql := range recorder.statements[:2] {
+		assert.Contains(t, sql, `path_ltree <@ CAST('1.3' AS ltree)`)
+	}
+	assert.Contains(t, recorder.statements[2], `path_ltree @> CAST('1.3.5' AS ltree)`)
+	assert.Contains(t, recorder.statements[3], `path_ltree <@ CAST('1.3' AS ltree)`)
+	assert.Contains(t, recorder.statements[4], `path_ltree <@ CAST('1.3' AS ltree)`)
+	assert.Contains(t, recorder.statements[4], `'/2/3/' || SUBSTR(path, 6)`)
+	for _, sql := range recorder.statements {
+		assert.NotContains(t, sql, "LIKE")
+	}
+
+	// 根路径没有祖先，不查询
+	ancestors, err := repo.FindAncestors(ctx, "/")
+	require.NoError(t, err)
+	assert.Empty(t, ancestors)
+	assert.Len(t, recorder.statements, 5)
+}
+
+// TestLtreeRepository_PostgreSQL 在真实 PostgreSQL 上验证 ltree 列、运算符与子树移动
+func TestLtreeRepository_PostgreSQL(t *testing.T) {
+	repo, db := newLtreePostgresRepo(t)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	// 重复迁移时跳过已存在的扩展、列和索引
+	require.NoError(t, repo.MigrateLtree(ctx))
+
+	tech := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &tech.ID)
+	concurrency := mustCreateFolder(t, svc, "并发", &golang.ID)
+	life := mustCreateFolder(t, svc, "生活", nil)
+
+	// ltree 生成列由 path 派生
+	var label string
+	require.NoError(t, db.Table(repo.tableName).Select(ltreeColumn+"::text").Where("id = ?", concurrency.ID).Scan(&label).Error)
+	assert.Equal(t, pathToLtree(concurrency.Path), label)
+
+	subtree, err := repo.FindByPath(ctx, tech.Path)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{tech.ID, golang.ID, concurrency.ID}, folderIDs(subtree))
+
+	limited, err := repo.FindByPathMaxDepth(ctx, tech.Path, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{tech.ID, golang.ID}, folderIDs(limited))
+
+	ancestors, err := repo.FindAncestors(ctx, concurrency.Path)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{tech.ID, golang.ID, concurrency.ID}, folderIDs(ancestors))
+
+	count, err := repo.CountDescendants(ctx, tech.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	// 移动子树：path 前缀经 || 与 SUBSTR 改写，ltree 列随之更新
+	require.NoError(t, svc.MoveFolder(ctx, golang.ID, &life.ID))
+	moved, err := repo.FindByID(ctx, concurrency.ID)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("/%d/%d/%d/", life.ID, golang.ID, concurrency.ID), moved.Path)
+	assert.Equal(t, 2, moved.Depth)
+
+	subtree, err = repo.FindByPath(ctx, life.Path)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{life.ID, golang.ID, concurrency.ID}, folderIDs(subtree))
+	subtree, err = repo.FindByPath(ctx, tech.Path)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{tech.ID}, folderIDs(subtree))
+
+	// 事务内仍使用 ltree 查询
+	err = repo.WithTx(ctx, func(tx Repository) error {
+		_, ok := tx.(*LtreeRepository)
+		assert.True(t, ok)
+		folders, err := tx.FindByPath(ctx, life.Path)
+		assert.Len(t, folders, 3)
+		return err
+	})
+	require.NoError(t, err)
+
+	// 回收站按 ltree 查询子树
+	movedGo, err := repo.FindByID(ctx, golang.ID)
+	require.NoError(t, err)
+	_, err = svc.DeleteFolderWithOptions(ctx, golang.ID, &DeleteOptions{Strategy: DeleteCascade})
+	require.NoError(t, err)
+	deleted, err := repo.FindDeletedByPath(ctx, movedGo.Path)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{golang.ID, concurrency.ID}, folderIDs(deleted))
+}
+
+// folderIDs 返回文件夹 ID 列表
+func folderIDs(folders []*model.Folder) []uint {
+	ids := make([]uint, 0, len(folders))
+	for _, f := range folders {
+		ids = append(ids, f.ID)
+	}
+	return ids
+}
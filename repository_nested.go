@@ -0,0 +1,480 @@
+package folder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/KOMKZ/go-yogan-domain-folder/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NestedSetRepository 基于嵌套集合（lft/rgt）的 Repository 实现，适合读多写少的树
+// 在 GormRepository 的基础上维护 <table>_nested_set (folder_id, lft, rgt)，
+// 子树、祖先和子孙计数都是一次区间查询；创建、移动和永久删除需要重排区间编号
+// 编号覆盖整张表（包括回收站中的记录和所有作用域），path、depth 列仍照常维护
+// 改写编号的操作先锁定区间表中的哨兵行，不同作用域的写操作同样互相串行
+type NestedSetRepository struct {
+	*GormRepository
+	nestedTable string
+}
+
+// NewNestedSetRepository 创建嵌套集合 Repository，区间表名为 tableName + "_nested_set"
+func NewNestedSetRepository(db *gorm.DB, tableName string, opts ...GormOption) *NestedSetRepository {
+	return &NestedSetRepository{
+		GormRepository: NewGormRepository(db, tableName, opts...),
+		nestedTable:    tableName + "_nested_set",
+	}
+}
+
+// NestedSetTableName 区间表名
+func (r *NestedSetRepository) NestedSetTableName() string {
+	return r.nestedTable
+}
+
+// nestedSetSentinel 区间表中用作写锁的哨兵行 folder_id，其 lft、rgt 为 0，不落入任何节点的区间
+const nestedSetSentinel = 0
+
+// MigrateNestedSetTable 创建区间表及写锁哨兵行
+func (r *NestedSetRepository) MigrateNestedSetTable(ctx context.Context) error {
+	if err := r.db.WithContext(ctx).Table(r.nestedTable).AutoMigrate(&model.FolderNestedSet{}); err != nil {
+		return err
+	}
+	return r.createSentinel(ctx)
+}
+
+// createSentinel 创建写锁哨兵行，已存在时跳过
+func (r *NestedSetRepository) createSentinel(ctx context.Context) error {
+	return r.nested(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.FolderNestedSet{FolderID: nestedSetSentinel}).Error
+}
+
+// lockNestedSet 以 SELECT ... FOR UPDATE 锁定哨兵行（不存在时先创建），串行化所有改写编号的操作
+// 必须在事务中、首次读取区间之前调用，锁在事务提交或回滚时释放；
+// SQLite 不支持行锁，由其写事务自身串行
+func (r *NestedSetRepository) lockNestedSet(ctx context.Context) error {
+	for attempt := 0; ; attempt++ {
+		var rows []*model.FolderNestedSet
+		err := r.nested(ctx).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("folder_id = ?", nestedSetSentinel).
+			Find(&rows).Error
+		if err != nil || len(rows) > 0 || attempt > 0 {
+			return err
+		}
+		if err := r.createSentinel(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// locked 在事务中锁定区间表后执行 fn；已处于事务中时由 GORM 使用 SavePoint 嵌套
+func (r *NestedSetRepository) locked(ctx context.Context, fn func(repo *NestedSetRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		repo := r.withDB(tx)
+		if err := repo.lockNestedSet(ctx); err != nil {
+			return err
+		}
+		return fn(repo)
+	})
+}
+
+// RebuildNestedSet 根据 parent_id 和同级顺序为整张表（包括回收站中的记录和所有作用域）重新编号
+// 用于从物化路径表转换；父节点缺失或处于环上的节点作为根节点编号，转换前建议先执行 CheckIntegrity
+func (r *NestedSetRepository) RebuildNestedSet(ctx context.Context) error {
+	return r.locked(ctx, func(repo *NestedSetRepository) error {
+		tx := repo.db
+		var folders []*model.Folder
+		err := tx.Table(r.tableName).Unscoped().
+			Select("id", "parent_id").
+			Order("sort_order ASC, sort_key ASC, id ASC").
+			Find(&folders).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Table(r.nestedTable).Where("folder_id <> ?", nestedSetSentinel).Delete(&model.FolderNestedSet{}).Error; err != nil {
+			return err
+		}
+
+		exists := make(map[uint]bool, len(folders))
+		for _, f := range folders {
+			exists[f.ID] = true
+		}
+		children := make(map[uint][]*model.Folder)
+		var roots []*model.Folder
+		for _, f := range folders {
+			if f.ParentID == nil || !exists[*f.ParentID] {
+				roots = append(roots, f)
+				continue
+			}
+			children[*f.ParentID] = append(children[*f.ParentID], f)
+		}
+
+		rows := make([]*model.FolderNestedSet, 0, len(folders))
+		visited := make(map[uint]bool, len(folders))
+		counter := 0
+		var walk func(f *model.Folder)
+		walk = func(f *model.Folder) {
+			visited[f.ID] = true
+			counter++
+			row := &model.FolderNestedSet{FolderID: f.ID, Lft: counter}
+			rows = append(rows, row)
+			for _, child := range children[f.ID] {
+				if !visited[child.ID] {
+					walk(child)
+				}
+			}
+			counter++
+			row.Rgt = counter
+		}
+		for _, f := range roots {
+			walk(f)
+		}
+		// 环上的节点无法从根到达，按 ID 顺序将首个未访问节点作为根
+		sort.Slice(folders, func(i, j int) bool { return folders[i].ID < folders[j].ID })
+		for _, f := range folders {
+			if !visited[f.ID] {
+				walk(f)
+			}
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.Table(r.nestedTable).CreateInBatches(rows, 500).Error
+	})
+}
+
+// nested 返回区间表的 DB 实例
+func (r *NestedSetRepository) nested(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Table(r.nestedTable)
+}
+
+// current 返回读取最新已提交编号的区间表 DB 实例
+// MySQL 可重复读下普通 SELECT 使用事务开始时的快照，持有写锁后仍可能读到旧编号，因此改用锁定读
+func (r *NestedSetRepository) current(ctx context.Context) *gorm.DB {
+	db := r.nested(ctx)
+	if r.db.Dialector.Name() == "mysql" {
+		db = db.Clauses(clause.Locking{Strength: "SHARE"})
+	}
+	return db
+}
+
+// interval 读取节点的区间
+func (r *NestedSetRepository) interval(ctx context.Context, id uint) (lft, rgt int, err error) {
+	var row model.FolderNestedSet
+	if err := r.current(ctx).Where("folder_id = ?", id).First(&row).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, 0, fmt.Errorf("folder: no nested set entry for folder %d, run RebuildNestedSet", id)
+		}
+		return 0, 0, err
+	}
+	return row.Lft, row.Rgt, nil
+}
+
+// subtreeIDs 返回节点 id 子树中（includeSelf 为 false 时不含自身）所有节点 ID 的子查询
+// 区间表自连接，随外层查询一次执行，无需先读取区间
+func (r *NestedSetRepository) subtreeIDs(ctx context.Context, id uint, includeSelf bool) *gorm.DB {
+	on := "d.lft >= n.lft AND d.rgt <= n.rgt"
+	if !includeSelf {
+		on = "d.lft > n.lft AND d.rgt < n.rgt"
+	}
+	return r.nested(ctx).Table("? AS d", clause.Table{Name: r.nestedTable}).
+		Select("d.folder_id").
+		Joins("JOIN ? AS n ON "+on, clause.Table{Name: r.nestedTable}).
+		Where("n.folder_id = ?", id)
+}
+
+// ancestorIDs 返回节点 id 的所有祖先（包含自身）ID 的子查询
+func (r *NestedSetRepository) ancestorIDs(ctx context.Context, id uint) *gorm.DB {
+	return r.nested(ctx).Table("? AS a", clause.Table{Name: r.nestedTable}).
+		Select("a.folder_id").
+		Joins("JOIN ? AS n ON a.lft <= n.lft AND a.rgt >= n.rgt", clause.Table{Name: r.nestedTable}).
+		Where("n.folder_id = ?", id)
+}
+
+// checkInterval 查询结果为空时区分节点不可见与缺少区间记录，后者返回需要重建的错误
+func (r *NestedSetRepository) checkInterval(ctx context.Context, id uint) error {
+	_, _, err := r.interval(ctx, id)
+	return err
+}
+
+// shift 将编号不小于 from 的 lft、rgt 分别加上 delta
+func (r *NestedSetRepository) shift(ctx context.Context, from, delta int) error {
+	if err := r.nested(ctx).Where("lft >= ?", from).Update("lft", gorm.Expr("lft + ?", delta)).Error; err != nil {
+		return err
+	}
+	return r.nested(ctx).Where("rgt >= ?", from).Update("rgt", gorm.Expr("rgt + ?", delta)).Error
+}
+
+// appendPosition 返回作为 parentID 最后一个子节点（或最后一个根节点）插入时的 lft
+func (r *NestedSetRepository) appendPosition(ctx context.Context, parentID *uint) (int, error) {
+	if parentID != nil {
+		_, rgt, err := r.interval(ctx, *parentID)
+		return rgt, err
+	}
+	var max *int
+	if err := r.current(ctx).Select("MAX(rgt)").Scan(&max).Error; err != nil {
+		return 0, err
+	}
+	if max == nil || *max < 0 {
+		return 1, nil
+	}
+	return *max + 1, nil
+}
+
+// WithTx 在数据库事务中执行 fn
+func (r *NestedSetRepository) WithTx(ctx context.Context, fn func(repo Repository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(r.withDB(tx))
+	})
+}
+
+// withDB 返回使用指定 DB 实例的仓储副本
+func (r *NestedSetRepository) withDB(db *gorm.DB) *NestedSetRepository {
+	return &NestedSetRepository{
+		GormRepository: r.GormRepository.withDB(db),
+		nestedTable:    r.nestedTable,
+	}
+}
+
+// Create 创建文件夹，作为父节点的最后一个子节点编号
+func (r *NestedSetRepository) Create(ctx context.Context, folder *model.Folder) error {
+	return r.locked(ctx, func(repo *NestedSetRepository) error {
+		if err := repo.GormRepository.Create(ctx, folder); err != nil {
+			return err
+		}
+		pos, err := repo.appendPosition(ctx, folder.ParentID)
+		if err != nil {
+			return err
+		}
+		if err := repo.shift(ctx, pos, 2); err != nil {
+			return err
+		}
+		return repo.nested(ctx).Create(&model.FolderNestedSet{FolderID: folder.ID, Lft: pos, Rgt: pos + 1}).Error
+	})
+}
+
+// Update 更新文件夹，父节点变化时移动子树区间
+func (r *NestedSetRepository) Update(ctx context.Context, folder *model.Folder) error {
+	oldParentID, err := r.storedParentID(ctx, folder.ID)
+	if err != nil {
+		return err
+	}
+	if sameParent(oldParentID, folder.ParentID) {
+		return r.GormRepository.Update(ctx, folder)
+	}
+	return r.locked(ctx, func(repo *NestedSetRepository) error {
+		if err := repo.GormRepository.Update(ctx, folder); err != nil {
+			return err
+		}
+		return repo.moveSubtree(ctx, folder.ID, folder.ParentID)
+	})
+}
+
+// Restore 恢复文件夹，恢复到其他父节点时移动子树区间
+func (r *NestedSetRepository) Restore(ctx context.Context, folder *model.Folder) error {
+	oldParentID, err := r.storedParentID(ctx, folder.ID)
+	if err != nil {
+		return err
+	}
+	if sameParent(oldParentID, folder.ParentID) {
+		return r.GormRepository.Restore(ctx, folder)
+	}
+	return r.locked(ctx, func(repo *NestedSetRepository) error {
+		if err := repo.GormRepository.Restore(ctx, folder); err != nil {
+			return err
+		}
+		return repo.moveSubtree(ctx, folder.ID, folder.ParentID)
+	})
+}
+
+// moveSubtree 将 id 的子树移到 newParentID 下作为最后一个子节点，调用方需已持有 lockNestedSet：
+// 先将子树区间取负暂存，收拢原位置的空隙，在目标位置腾出空隙后再整体平移回来
+func (r *NestedSetRepository) moveSubtree(ctx context.Context, id uint, newParentID *uint) error {
+	lft, rgt, err := r.interval(ctx, id)
+	if err != nil {
+		return err
+	}
+	width := rgt - lft + 1
+
+	if newParentID != nil {
+		parentLft, parentRgt, err := r.interval(ctx, *newParentID)
+		if err != nil {
+			return err
+		}
+		if parentLft >= lft && parentRgt <= rgt {
+			return ErrCircularReference
+		}
+	}
+
+	if err := r.nested(ctx).Where("lft >= ? AND rgt <= ?", lft, rgt).Updates(map[string]interface{}{
+		"lft": gorm.Expr("-lft"),
+		"rgt": gorm.Expr("-rgt"),
+	}).Error; err != nil {
+		return err
+	}
+	if err := r.shift(ctx, rgt+1, -width); err != nil {
+		return err
+	}
+
+	pos, err := r.appendPosition(ctx, newParentID)
+	if err != nil {
+		return err
+	}
+	if err := r.shift(ctx, pos, width); err != nil {
+		return err
+	}
+
+	offset := pos - lft
+	return r.nested(ctx).Where("lft < 0").Updates(map[string]interface{}{
+		"lft": gorm.Expr("-lft + ?", offset),
+		"rgt": gorm.Expr("-rgt + ?", offset),
+	}).Error
+}
+
+// removeNode 删除单个节点的区间，其子孙节点上移一层，调用方需已持有 lockNestedSet
+func (r *NestedSetRepository) removeNode(ctx context.Context, id uint) error {
+	lft, rgt, err := r.interval(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := r.nested(ctx).Where("folder_id = ?", id).Delete(&model.FolderNestedSet{}).Error; err != nil {
+		return err
+	}
+	if rgt-lft > 1 {
+		if err := r.nested(ctx).Where("lft > ? AND rgt < ?", lft, rgt).Updates(map[string]interface{}{
+			"lft": gorm.Expr("lft - 1"),
+			"rgt": gorm.Expr("rgt - 1"),
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return r.shift(ctx, rgt+1, -2)
+}
+
+// PurgeDeletedBefore 永久删除在 before 之前进入回收站的文件夹，并收拢区间编号
+func (r *NestedSetRepository) PurgeDeletedBefore(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+	err := r.locked(ctx, func(repo *NestedSetRepository) error {
+		var ids []uint
+		err := repo.table(ctx).Unscoped().
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+			Pluck("id", &ids).Error
+		if err != nil {
+			return err
+		}
+		purged, err = repo.GormRepository.PurgeDeletedBefore(ctx, before)
+		if err != nil || purged == 0 {
+			return err
+		}
+
+		// 从右向左删除，已处理的节点不影响尚未处理节点的编号
+		var rows []*model.FolderNestedSet
+		if err := repo.current(ctx).Where("folder_id IN ?", ids).Order("lft DESC").Find(&rows).Error; err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if err := repo.removeNode(ctx, row.FolderID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return purged, nil
+}
+
+// FindByPath 查询路径最后一级节点的子树（包含自身）
+func (r *NestedSetRepository) FindByPath(ctx context.Context, pathPrefix string) ([]*model.Folder, error) {
+	id, ok := lastPathID(pathPrefix)
+	if !ok {
+		return r.GormRepository.FindByPath(ctx, pathPrefix)
+	}
+	var folders []*model.Folder
+	err := r.table(ctx).
+		Where("id IN (?)", r.subtreeIDs(ctx, id, true)).
+		Order("depth ASC, sort_order ASC, sort_key ASC, id ASC").
+		Find(&folders).Error
+	if err == nil && len(folders) == 0 {
+		err = r.checkInterval(ctx, id)
+	}
+	return folders, err
+}
+
+// FindByPathMaxDepth 查询路径最后一级节点的子树中深度不超过 maxDepth 的节点
+func (r *NestedSetRepository) FindByPathMaxDepth(ctx context.Context, pathPrefix string, maxDepth int) ([]*model.Folder, error) {
+	id, ok := lastPathID(pathPrefix)
+	if !ok {
+		return r.GormRepository.FindByPathMaxDepth(ctx, pathPrefix, maxDepth)
+	}
+	var folders []*model.Folder
+	err := r.table(ctx).
+		Where("id IN (?)", r.subtreeIDs(ctx, id, true)).
+		Where("depth <= ?", maxDepth).
+		Order("depth ASC, sort_order ASC, sort_key ASC, id ASC").
+		Find(&folders).Error
+	if err == nil && len(folders) == 0 {
+		err = r.checkInterval(ctx, id)
+	}
+	return folders, err
+}
+
+// FindAncestors 查询路径最后一级节点的所有祖先（包含自身），从根开始排列
+func (r *NestedSetRepository) FindAncestors(ctx context.Context, path string) ([]*model.Folder, error) {
+	id, ok := lastPathID(path)
+	if !ok {
+		return []*model.Folder{}, nil
+	}
+	var folders []*model.Folder
+	err := r.table(ctx).
+		Where("id IN (?)", r.ancestorIDs(ctx, id)).
+		Order("depth ASC").
+		Find(&folders).Error
+	if err == nil && len(folders) == 0 {
+		err = r.checkInterval(ctx, id)
+	}
+	return folders, err
+}
+
+// CountDescendants 统计子孙节点数量（不含自身）
+// 连同自身一起计数：结果为 0 说明节点不存在（或缺少区间记录），否则减去自身
+func (r *NestedSetRepository) CountDescendants(ctx context.Context, id uint) (int64, error) {
+	var count int64
+	err := r.aggregate(ctx).
+		Where("id IN (?)", r.subtreeIDs(ctx, id, true)).
+		Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	if count == 0 {
+		if _, err := r.FindByID(ctx, id); err != nil {
+			return 0, err
+		}
+		return 0, r.checkInterval(ctx, id)
+	}
+	return count - 1, nil
+}
+
+// FindDeletedByPath 查询路径最后一级节点子树中已删除的节点（包含自身）
+func (r *NestedSetRepository) FindDeletedByPath(ctx context.Context, pathPrefix string) ([]*model.Folder, error) {
+	id, ok := lastPathID(pathPrefix)
+	if !ok {
+		return r.GormRepository.FindDeletedByPath(ctx, pathPrefix)
+	}
+	var folders []*model.Folder
+	err := r.table(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Where("id IN (?)", r.subtreeIDs(ctx, id, true)).
+		Order("depth ASC, sort_order ASC, sort_key ASC, id ASC").
+		Find(&folders).Error
+	if err == nil && len(folders) == 0 {
+		err = r.checkInterval(ctx, id)
+	}
+	return folders, err
+}
@@ -0,0 +1,321 @@
+package folder
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/KOMKZ/go-yogan-domain-folder/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newNestedSetTestRepo 创建嵌套集合 Repository 并建表
+func newNestedSetTestRepo(t *testing.T) (*gorm.DB, *NestedSetRepository) {
+	t.Helper()
+	db := newTestDB(t)
+	repo := NewNestedSetRepository(db, testTableName)
+	require.NoError(t, repo.MigrateNestedSetTable(context.Background()))
+	return db, repo
+}
+
+// assertNestedSetConsistent 校验区间编号连续，且区间包含关系与 parent_id（包括回收站中的记录）一致
+func assertNestedSetConsistent(t *testing.T, db *gorm.DB, repo *NestedSetRepository) {
+	t.Helper()
+	var folders []*model.Folder
+	require.NoError(t, db.Table(testTableName).Unscoped().Find(&folders).Error)
+	var rows []*model.FolderNestedSet
+	require.NoError(t, db.Table(repo.NestedSetTableName()).Where("folder_id <> ?", nestedSetSentinel).Find(&rows).Error)
+	require.Len(t, rows, len(folders))
+
+	intervals := make(map[uint]*model.FolderNestedSet, len(rows))
+	numbers := make(map[int]bool, 2*len(rows))
+	for _, row := range rows {
+		assert.Less(t, row.Lft, row.Rgt)
+		intervals[row.FolderID] = row
+		numbers[row.Lft] = true
+		numbers[row.Rgt] = true
+	}
+	for i := 1; i <= 2*len(rows); i++ {
+		assert.True(t, numbers[i], "missing number %d", i)
+	}
+
+	parents := make(map[uint]*uint, len(folders))
+	for _, f := range folders {
+		parents[f.ID] = f.ParentID
+	}
+	isAncestor := func(a, b uint) bool {
+		for p := parents[b]; p != nil; p = parents[*p] {
+			if *p == a {
+				return true
+			}
+		}
+		return false
+	}
+	for _, a := range folders {
+		for _, b := range folders {
+			if a.ID == b.ID {
+				continue
+			}
+			ia, ib := intervals[a.ID], intervals[b.ID]
+			contains := ia.Lft < ib.Lft && ib.Rgt < ia.Rgt
+			assert.Equal(t, isAncestor(a.ID, b.ID), contains, "ancestor %d of %d", a.ID, b.ID)
+		}
+	}
+}
+
+// TestNestedSetRepository_CreateAndQuery 测试创建节点编号，并按区间查询子树与祖先
+func TestNestedSetRepository_CreateAndQuery(t *testing.T) {
+	db, repo := newNestedSetTestRepo(t)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	tech := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &tech.ID)
+	concurrency := mustCreateFolder(t, svc, "并发", &golang.ID)
+	mustCreateFolder(t, svc, "Rust", &tech.ID)
+	life := mustCreateFolder(t, svc, "生活", nil)
+	mustCreateFolder(t, svc, "旅行", &life.ID)
+	mustCreateFolder(t, svc, "Channel", &concurrency.ID)
+	assertNestedSetConsistent(t, db, repo)
+
+	ancestors, err := svc.GetAncestors(ctx, concurrency.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"技术", "Go", "并发"}, folderNames(ancestors))
+
+	nodes, err := svc.GetSubTree(ctx, tech.ID)
+	require.NoError(t, err)
+	require.Len(t, nodes, 2)
+	assert.Equal(t, "Go", nodes[0].Name)
+	assert.Equal(t, 2, nodes[0].DescendantCount)
+
+	count, err := svc.CountDescendants(ctx, tech.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), count)
+
+	// 回收站中的子孙不计入
+	require.NoError(t, svc.DeleteFolder(ctx, mustCreateFolder(t, svc, "草稿", &golang.ID).ID))
+	count, err = svc.CountDescendants(ctx, tech.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), count)
+	assertNestedSetConsistent(t, db, repo)
+}
+
+// TestNestedSetRepository_SingleStatementQueries 测试子树、祖先与子孙计数各为一条语句，缺少区间记录时报错
+func TestNestedSetRepository_SingleStatementQueries(t *testing.T) {
+	db, repo := newNestedSetTestRepo(t)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	tech := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &tech.ID)
+	concurrency := mustCreateFolder(t, svc, "并发", &golang.ID)
+
+	recorder := &sqlRecorder{Interface: logger.Default.LogMode(logger.Silent)}
+	recorded := NewNestedSetRepository(db.Session(&gorm.Session{Logger: recorder}), testTableName)
+
+	subtree, err := recorded.FindByPath(ctx, tech.Path)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{tech.ID, golang.ID, concurrency.ID}, folderIDs(subtree))
+	assert.Len(t, recorder.statements, 1)
+
+	ancestors, err := recorded.FindAncestors(ctx, concurrency.Path)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{tech.ID, golang.ID, concurrency.ID}, folderIDs(ancestors))
+	assert.Len(t, recorder.statements, 2)
+
+	count, err := recorded.CountDescendants(ctx, tech.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	assert.Len(t, recorder.statements, 3)
+
+	_, err = recorded.CountDescendants(ctx, 999)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// 缺少区间记录时提示重建
+	require.NoError(t, db.Table(repo.NestedSetTableName()).Where("folder_id = ?", golang.ID).Delete(&model.FolderNestedSet{}).Error)
+	_, err = repo.FindByPath(ctx, golang.Path)
+	assert.ErrorContains(t, err, "RebuildNestedSet")
+	_, err = repo.CountDescendants(ctx, golang.ID)
+	assert.ErrorContains(t, err, "RebuildNestedSet")
+}
+
+// TestNestedSetRepository_Move 测试移动子树时重排区间
+func TestNestedSetRepository_Move(t *testing.T) {
+	db, repo := newNestedSetTestRepo(t)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	tech := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &tech.ID)
+	concurrency := mustCreateFolder(t, svc, "并发", &golang.ID)
+	channels := mustCreateFolder(t, svc, "Channel", &concurrency.ID)
+	mustCreateFolder(t, svc, "Rust", &tech.ID)
+	archive := mustCreateFolder(t, svc, "归档", nil)
+	old := mustCreateFolder(t, svc, "旧文", &archive.ID)
+
+	// 向右移动
+	require.NoError(t, svc.MoveFolder(ctx, golang.ID, &old.ID))
+	assertNestedSetConsistent(t, db, repo)
+	ancestors, err := svc.GetAncestors(ctx, channels.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"归档", "旧文", "Go", "并发", "Channel"}, folderNames(ancestors))
+
+	// 向左移动
+	require.NoError(t, svc.MoveFolder(ctx, concurrency.ID, &tech.ID))
+	assertNestedSetConsistent(t, db, repo)
+
+	// 移到根节点
+	require.NoError(t, svc.MoveFolder(ctx, archive.ID, nil))
+	require.NoError(t, svc.MoveFolder(ctx, tech.ID, nil))
+	assertNestedSetConsistent(t, db, repo)
+
+	count, err := svc.CountDescendants(ctx, tech.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	// 不能移动到自己的子孙下
+	err = svc.MoveFolder(ctx, tech.ID, &channels.ID)
+	assert.ErrorIs(t, err, ErrCircularReference)
+	assertNestedSetConsistent(t, db, repo)
+}
+
+// TestNestedSetRepository_DeleteRestorePurge 测试删除策略、回收站恢复与永久删除后的编号
+func TestNestedSetRepository_DeleteRestorePurge(t *testing.T) {
+	db, repo := newNestedSetTestRepo(t)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	tech := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &tech.ID)
+	concurrency := mustCreateFolder(t, svc, "并发", &golang.ID)
+	life := mustCreateFolder(t, svc, "生活", nil)
+	travel := mustCreateFolder(t, svc, "旅行", &life.ID)
+	mustCreateFolder(t, svc, "照片", &travel.ID)
+
+	// 子节点上移
+	_, err := svc.DeleteFolderWithOptions(ctx, golang.ID, &DeleteOptions{Strategy: DeleteReparent})
+	require.NoError(t, err)
+	assertNestedSetConsistent(t, db, repo)
+	ancestors, err := svc.GetAncestors(ctx, concurrency.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"技术", "并发"}, folderNames(ancestors))
+
+	// 父节点仍在回收站中时恢复到根节点
+	_, err = svc.DeleteFolderWithOptions(ctx, life.ID, &DeleteOptions{Strategy: DeleteCascade})
+	require.NoError(t, err)
+	result, err := svc.RestoreFolder(ctx, travel.ID, &RestoreOptions{IncludeDescendants: true})
+	require.NoError(t, err)
+	assert.Len(t, result.RestoredIDs, 2)
+	assertNestedSetConsistent(t, db, repo)
+
+	// 永久删除后收拢编号
+	require.NoError(t, db.Table(testTableName).Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Update("deleted_at", time.Now().Add(-48*time.Hour)).Error)
+	purged, err := svc.PurgeTrash(ctx, 24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+	assertNestedSetConsistent(t, db, repo)
+
+	count, err := svc.CountDescendants(ctx, travel.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+// TestNestedSetRepository_RollbackOnFailure 测试事务失败时区间编号一并回滚
+func TestNestedSetRepository_RollbackOnFailure(t *testing.T) {
+	db, repo := newNestedSetTestRepo(t)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	tech := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &tech.ID)
+	mustCreateFolder(t, svc, "并发", &golang.ID)
+	life := mustCreateFolder(t, svc, "生活", nil)
+
+	faulty := NewService(&faultyRepository{Repository: repo, failOn: "UpdateChildrenPathAndDepth"})
+	err := faulty.MoveFolder(ctx, golang.ID, &life.ID)
+	assert.ErrorIs(t, err, errInjected)
+	assertNestedSetConsistent(t, db, repo)
+
+	count, err := svc.CountDescendants(ctx, tech.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+// TestNestedSetRepository_RebuildNestedSet 测试从物化路径表转换
+func TestNestedSetRepository_RebuildNestedSet(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	// 先以物化路径仓储写入数据
+	svc := NewService(NewGormRepository(db, testTableName))
+	tech := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &tech.ID)
+	mustCreateFolder(t, svc, "Rust", &tech.ID)
+	trashed := mustCreateFolder(t, svc, "草稿", &golang.ID)
+	mustCreateFolder(t, svc, "生活", nil)
+	require.NoError(t, svc.DeleteFolder(ctx, trashed.ID))
+
+	repo := NewNestedSetRepository(db, testTableName)
+	require.NoError(t, repo.MigrateNestedSetTable(ctx))
+	require.NoError(t, repo.RebuildNestedSet(ctx))
+	assertNestedSetConsistent(t, db, repo)
+
+	// 重复执行结果不变，转换后可继续写入
+	require.NoError(t, repo.RebuildNestedSet(ctx))
+	nested := NewService(repo)
+	mustCreateFolder(t, nested, "并发", &golang.ID)
+	assertNestedSetConsistent(t, db, repo)
+
+	deleted, err := repo.FindDeletedByPath(ctx, tech.Path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"草稿"}, folderNames(deleted))
+
+	count, err := nested.CountDescendants(ctx, tech.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+}
+
+// TestNestedSetRepository_ConcurrentCreate 测试不同父节点下并发创建时编号不交叠
+func TestNestedSetRepository_ConcurrentCreate(t *testing.T) {
+	ctx := context.Background()
+	dsn := filepath.Join(t.TempDir(), "folder.db") + "?_busy_timeout=5000&_txlock=immediate"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.Table(testTableName).AutoMigrate(&model.Folder{}))
+	repo := NewNestedSetRepository(db, testTableName)
+	require.NoError(t, repo.MigrateNestedSetTable(ctx))
+	svc := NewService(repo)
+
+	const parents, callers = 4, 8
+	roots := make([]*model.Folder, parents)
+	for i := range roots {
+		roots[i] = mustCreateFolder(t, svc, fmt.Sprintf("R%d", i), nil)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := svc.CreateFolder(ctx, &CreateFolderInput{Name: fmt.Sprintf("C%d", i), ParentID: &roots[i%parents].ID})
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	assertNestedSetConsistent(t, db, repo)
+	for _, root := range roots {
+		count, err := repo.CountDescendants(ctx, root.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(callers/parents), count)
+	}
+}
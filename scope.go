@@ -0,0 +1,18 @@
+package folder
+
+import "context"
+
+// scopeKey context 中作用域值的键
+type scopeKey struct{}
+
+// ContextWithScope 返回携带作用域值（如租户 ID、工作空间 ID）的 context
+// 配合 WithScopeColumn 使用，同一张表内按作用域隔离多棵树
+func ContextWithScope(ctx context.Context, value interface{}) context.Context {
+	return context.WithValue(ctx, scopeKey{}, value)
+}
+
+// ScopeFromContext 从 context 中读取作用域值
+func ScopeFromContext(ctx context.Context) (interface{}, bool) {
+	value := ctx.Value(scopeKey{})
+	return value, value != nil
+}
@@ -0,0 +1,133 @@
+package folder
+
+import (
+	"context"
+	"strings"
+
+	"github.com/KOMKZ/go-yogan-domain-folder/model"
+)
+
+// MatchMode 名称匹配方式
+type MatchMode string
+
+const (
+	MatchSubstring MatchMode = "substring" // 名称包含关键字（默认）
+	MatchPrefix    MatchMode = "prefix"    // 名称以关键字开头
+)
+
+const (
+	defaultSearchPageSize = 20
+	maxSearchPageSize     = 100
+)
+
+// NameQuery 仓储层名称搜索条件，匹配不区分大小写
+type NameQuery struct {
+	Keyword string
+	Match   MatchMode
+	Pinyin  bool // 同时匹配拼音全拼与首字母
+	Offset  int
+	Limit   int
+}
+
+// SearchOptions 搜索选项
+type SearchOptions struct {
+	Match    MatchMode
+	Page     int // 页码，从 1 开始
+	PageSize int // 每页条数，默认 20，最大 100
+}
+
+// SearchHit 搜索命中项
+type SearchHit struct {
+	Folder     *model.Folder   `json:"folder"`
+	Breadcrumb []*model.Folder `json:"breadcrumb"` // 祖先链（根在前，不含自身）
+}
+
+// SearchResult 搜索结果
+type SearchResult struct {
+	Items    []*SearchHit `json:"items"`
+	Total    int64        `json:"total"`
+	Page     int          `json:"page"`
+	PageSize int          `json:"pageSize"`
+}
+
+// SearchFolders 按名称搜索文件夹，不区分大小写，每个结果附带面包屑
+// 启用拼音索引时同时按全拼（"jishu"）和首字母（"jswz"）匹配
+func (s *Service) SearchFolders(ctx context.Context, query string, opts *SearchOptions) (*SearchResult, error) {
+	if opts == nil {
+		opts = &SearchOptions{}
+	}
+	page := opts.Page
+	if page < 1 {
+		page = 1
+	}
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultSearchPageSize
+	}
+	if pageSize > maxSearchPageSize {
+		pageSize = maxSearchPageSize
+	}
+
+	result := &SearchResult{Items: []*SearchHit{}, Page: page, PageSize: pageSize}
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return result, nil
+	}
+
+	folders, total, err := s.repo.SearchByName(ctx, &NameQuery{
+		Keyword: query,
+		Match:   opts.Match,
+		Pinyin:  s.config.PinyinIndex,
+		Offset:  (page - 1) * pageSize,
+		Limit:   pageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Total = total
+
+	// 一次性批量查询所有祖先
+	breadcrumbs, err := resolveBreadcrumbs(folders, func(ids []uint) ([]*model.Folder, error) {
+		return s.repo.FindByIDs(ctx, ids)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, f := range folders {
+		result.Items = append(result.Items, &SearchHit{Folder: f, Breadcrumb: breadcrumbs[i]})
+	}
+	return result, nil
+}
+
+// resolveBreadcrumbs 通过一次批量查询解析每个文件夹的祖先链（根在前，不含自身）
+func resolveBreadcrumbs(folders []*model.Folder, find func(ids []uint) ([]*model.Folder, error)) ([][]*model.Folder, error) {
+	seen := make(map[uint]bool)
+	var ancestorIDs []uint
+	for _, f := range folders {
+		for _, id := range parsePathIDs(f.Path) {
+			if id != f.ID && !seen[id] {
+				seen[id] = true
+				ancestorIDs = append(ancestorIDs, id)
+			}
+		}
+	}
+	ancestors, err := find(ancestorIDs)
+	if err != nil {
+		return nil, err
+	}
+	ancestorMap := make(map[uint]*model.Folder, len(ancestors))
+	for _, a := range ancestors {
+		ancestorMap[a.ID] = a
+	}
+
+	breadcrumbs := make([][]*model.Folder, len(folders))
+	for i, f := range folders {
+		breadcrumbs[i] = []*model.Folder{}
+		for _, id := range parsePathIDs(f.Path) {
+			if a, ok := ancestorMap[id]; ok && id != f.ID {
+				breadcrumbs[i] = append(breadcrumbs[i], a)
+			}
+		}
+	}
+	return breadcrumbs, nil
+}
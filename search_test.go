@@ -0,0 +1,157 @@
+package folder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/KOMKZ/go-yogan-domain-folder/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingRepository 统计面包屑相关查询次数
+type countingRepository struct {
+	Repository
+	findByIDs     int
+	findAncestors int
+}
+
+func (c *countingRepository) FindByIDs(ctx context.Context, ids []uint) ([]*model.Folder, error) {
+	c.findByIDs++
+	return c.Repository.FindByIDs(ctx, ids)
+}
+
+func (c *countingRepository) FindAncestors(ctx context.Context, path string) ([]*model.Folder, error) {
+	c.findAncestors++
+	return c.Repository.FindAncestors(ctx, path)
+}
+
+// hitNames 返回命中项名称
+func hitNames(result *SearchResult) []string {
+	names := make([]string, 0, len(result.Items))
+	for _, item := range result.Items {
+		names = append(names, item.Folder.Name)
+	}
+	return names
+}
+
+// TestSearchFolders_Substring 测试子串匹配、不区分大小写和面包屑
+func TestSearchFolders_Substring(t *testing.T) {
+	repo := &countingRepository{Repository: NewGormRepository(newTestDB(t), testTableName)}
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	tech := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Golang", &tech.ID)
+	mustCreateFolder(t, svc, "Go 并发", &golang.ID)
+	mustCreateFolder(t, svc, "Django", &tech.ID)
+	mustCreateFolder(t, svc, "生活", nil)
+
+	result, err := svc.SearchFolders(ctx, "GO", nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), result.Total)
+	assert.Equal(t, []string{"Golang", "Django", "Go 并发"}, hitNames(result))
+
+	deep := result.Items[2]
+	require.Len(t, deep.Breadcrumb, 2)
+	assert.Equal(t, "技术", deep.Breadcrumb[0].Name)
+	assert.Equal(t, "Golang", deep.Breadcrumb[1].Name)
+	assert.Len(t, findHit(result, "Golang").Breadcrumb, 1)
+
+	// 面包屑通过一次批量查询解析
+	assert.Equal(t, 1, repo.findByIDs)
+	assert.Equal(t, 0, repo.findAncestors)
+}
+
+// findHit 按名称查找命中项
+func findHit(result *SearchResult, name string) *SearchHit {
+	for _, item := range result.Items {
+		if item.Folder.Name == name {
+			return item
+		}
+	}
+	return nil
+}
+
+// TestSearchFolders_Prefix 测试前缀匹配
+func TestSearchFolders_Prefix(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	mustCreateFolder(t, svc, "Golang", nil)
+	mustCreateFolder(t, svc, "Django", nil)
+
+	result, err := svc.SearchFolders(ctx, "go", &SearchOptions{Match: MatchPrefix})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Golang"}, hitNames(result))
+}
+
+// TestSearchFolders_Pagination 测试分页
+func TestSearchFolders_Pagination(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	for _, name := range []string{"a1", "a2", "a3", "a4", "a5"} {
+		mustCreateFolder(t, svc, name, nil)
+	}
+
+	result, err := svc.SearchFolders(ctx, "a", &SearchOptions{Page: 2, PageSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), result.Total)
+	assert.Equal(t, 2, result.Page)
+	assert.Equal(t, []string{"a3", "a4"}, hitNames(result))
+
+	result, err = svc.SearchFolders(ctx, "a", &SearchOptions{Page: 3, PageSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a5"}, hitNames(result))
+
+	result, err = svc.SearchFolders(ctx, "a", &SearchOptions{PageSize: 1000})
+	require.NoError(t, err)
+	assert.Equal(t, maxSearchPageSize, result.PageSize)
+}
+
+// TestSearchFolders_EscapesWildcards 测试关键字中的通配符按字面匹配
+func TestSearchFolders_EscapesWildcards(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	mustCreateFolder(t, svc, "100%", nil)
+	mustCreateFolder(t, svc, "1000", nil)
+	mustCreateFolder(t, svc, "a_b", nil)
+	mustCreateFolder(t, svc, "axb", nil)
+	mustCreateFolder(t, svc, "wow!", nil)
+
+	result, err := svc.SearchFolders(ctx, "0%", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"100%"}, hitNames(result))
+
+	result, err = svc.SearchFolders(ctx, "a_", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a_b"}, hitNames(result))
+
+	result, err = svc.SearchFolders(ctx, "!", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"wow!"}, hitNames(result))
+}
+
+// TestSearchFolders_ExcludesDeleted 测试不返回已删除节点，空关键字返回空结果
+func TestSearchFolders_ExcludesDeleted(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	f := mustCreateFolder(t, svc, "Golang", nil)
+	require.NoError(t, svc.DeleteFolder(ctx, f.ID))
+
+	result, err := svc.SearchFolders(ctx, "go", nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), result.Total)
+	assert.Empty(t, result.Items)
+
+	result, err = svc.SearchFolders(ctx, "  ", nil)
+	require.NoError(t, err)
+	assert.Empty(t, result.Items)
+}
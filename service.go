@@ -8,9 +8,23 @@ import (
 	"github.com/KOMKZ/go-yogan-domain-folder/model"
 )
 
+// OrderMode 兄弟节点排序方式
+type OrderMode int
+
+const (
+	// OrderModeInteger 整数排序号（sort_order），插入中间位置时需要重排兄弟节点
+	OrderModeInteger OrderMode = iota
+	// OrderModeSortKey 字典序排序键（sort_key），插入、移动、排序只改写一行
+	OrderModeSortKey
+)
+
 // ServiceConfig 服务配置
 type ServiceConfig struct {
-	MaxDepth int // 最大深度限制，0 表示无限制
+	MaxDepth          int       // 最大深度限制，0 表示无限制
+	OrderMode         OrderMode // 兄弟节点排序方式，默认整数排序号
+	MaxSortKeyLength  int       // 排序键最大长度，超过后自动重排同级排序键，0 表示使用默认值
+	NamePathSeparator string    // 名称路径分隔符，默认 "/"
+	PinyinIndex       bool      // 创建和重命名时维护拼音索引，搜索时同时匹配全拼与首字母
 }
 
 // DefaultServiceConfig 默认配置
@@ -48,13 +62,27 @@ type CreateFolderInput struct {
 
 // CreateFolder 创建文件夹
 func (s *Service) CreateFolder(ctx context.Context, input *CreateFolderInput) (*model.Folder, error) {
+	var folder *model.Folder
+	err := s.repo.WithTx(ctx, func(repo Repository) error {
+		var err error
+		folder, err = s.createFolder(ctx, repo, input)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return folder, nil
+}
+
+// createFolder 在给定仓储（通常为事务仓储）上创建文件夹
+func (s *Service) createFolder(ctx context.Context, repo Repository, input *CreateFolderInput) (*model.Folder, error) {
 	// 验证名称
 	if err := s.validateName(input.Name); err != nil {
 		return nil, err
 	}
 
 	// 检查名称唯一性
-	exists, err := s.repo.ExistsByNameAndParent(ctx, input.Name, input.ParentID, nil)
+	exists, err := repo.ExistsByNameAndParent(ctx, input.Name, input.ParentID, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -67,7 +95,7 @@ func (s *Service) CreateFolder(ctx context.Context, input *CreateFolderInput) (*
 	var path string
 
 	if input.ParentID != nil {
-		parent, err := s.repo.FindByID(ctx, *input.ParentID)
+		parent, err := repo.FindByID(ctx, *input.ParentID)
 		if err != nil {
 			return nil, ErrParentNotFound
 		}
@@ -83,28 +111,27 @@ func (s *Service) CreateFolder(ctx context.Context, input *CreateFolderInput) (*
 		return nil, ErrMaxDepthExceeded
 	}
 
-	// 获取排序号
-	maxOrder, err := s.repo.FindMaxSortOrder(ctx, input.ParentID)
-	if err != nil {
-		return nil, err
+	folder := &model.Folder{
+		Name:     input.Name,
+		ParentID: input.ParentID,
+		Depth:    depth,
+		Path:     path, // 临时路径
 	}
 
-	folder := &model.Folder{
-		Name:      input.Name,
-		ParentID:  input.ParentID,
-		Depth:     depth,
-		Path:      path, // 临时路径
-		SortOrder: maxOrder + 1,
+	// 获取排序号
+	if err := appendOrder(ctx, repo, s.config, folder, input.ParentID); err != nil {
+		return nil, err
 	}
 
 	// 创建文件夹
-	if err := s.repo.Create(ctx, folder); err != nil {
+	s.indexPinyin(folder)
+	if err := repo.Create(ctx, folder); err != nil {
 		return nil, err
 	}
 
 	// 更新 path 包含自身 ID
 	folder.Path = path + fmt.Sprintf("%d/", folder.ID)
-	if err := s.repo.Update(ctx, folder); err != nil {
+	if err := repo.Update(ctx, folder); err != nil {
 		return nil, err
 	}
 
@@ -113,17 +140,35 @@ func (s *Service) CreateFolder(ctx context.Context, input *CreateFolderInput) (*
 
 // UpdateFolderInput 更新文件夹输入
 type UpdateFolderInput struct {
-	ID   uint
-	Name string
+	ID      uint
+	Name    string
+	Version *uint // 期望版本号，非空时与当前版本不一致返回 ErrVersionConflict
 }
 
 // UpdateFolder 更新文件夹
 func (s *Service) UpdateFolder(ctx context.Context, input *UpdateFolderInput) (*model.Folder, error) {
+	var folder *model.Folder
+	err := s.repo.WithTx(ctx, func(repo Repository) error {
+		var err error
+		folder, err = s.updateFolder(ctx, repo, input)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return folder, nil
+}
+
+// updateFolder 在给定仓储上更新文件夹
+func (s *Service) updateFolder(ctx context.Context, repo Repository, input *UpdateFolderInput) (*model.Folder, error) {
 	// 查找文件夹
-	folder, err := s.repo.FindByID(ctx, input.ID)
+	folder, err := repo.FindByID(ctx, input.ID)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkVersion(folder, input.Version); err != nil {
+		return nil, err
+	}
 
 	// 验证名称
 	if err := s.validateName(input.Name); err != nil {
@@ -131,7 +176,7 @@ func (s *Service) UpdateFolder(ctx context.Context, input *UpdateFolderInput) (*
 	}
 
 	// 检查名称唯一性（排除自身）
-	exists, err := s.repo.ExistsByNameAndParent(ctx, input.Name, folder.ParentID, &input.ID)
+	exists, err := repo.ExistsByNameAndParent(ctx, input.Name, folder.ParentID, &input.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -140,31 +185,18 @@ func (s *Service) UpdateFolder(ctx context.Context, input *UpdateFolderInput) (*
 	}
 
 	folder.Name = input.Name
-	if err := s.repo.Update(ctx, folder); err != nil {
+	s.indexPinyin(folder)
+	if err := repo.Update(ctx, folder); err != nil {
 		return nil, err
 	}
 
 	return folder, nil
 }
 
-// DeleteFolder 删除文件夹
+// DeleteFolder 删除文件夹，存在子节点时返回 ErrHasChildren
 func (s *Service) DeleteFolder(ctx context.Context, id uint) error {
-	// 检查是否存在
-	_, err := s.repo.FindByID(ctx, id)
-	if err != nil {
-		return err
-	}
-
-	// 检查是否有子节点
-	hasChildren, err := s.repo.HasChildren(ctx, id)
-	if err != nil {
-		return err
-	}
-	if hasChildren {
-		return ErrHasChildren
-	}
-
-	return s.repo.Delete(ctx, id)
+	_, err := s.DeleteFolderWithOptions(ctx, id, nil)
+	return err
 }
 
 // GetFolder 获取单个文件夹
@@ -183,7 +215,7 @@ func (s *Service) GetTree(ctx context.Context) ([]*model.FolderNode, error) {
 	if err != nil {
 		return nil, err
 	}
-	return buildTree(folders, nil), nil
+	return countTree(buildTree(folders, nil)), nil
 }
 
 // GetSubTree 获取子树
@@ -198,7 +230,12 @@ func (s *Service) GetSubTree(ctx context.Context, rootID uint) ([]*model.FolderN
 		return nil, err
 	}
 
-	return buildTree(descendants, &rootID), nil
+	return countTree(buildTree(descendants, &rootID)), nil
+}
+
+// CountDescendants 统计子孙节点数量（不含自身）
+func (s *Service) CountDescendants(ctx context.Context, id uint) (int64, error) {
+	return s.repo.CountDescendants(ctx, id)
 }
 
 // GetAncestors 获取祖先节点（面包屑）
@@ -213,10 +250,27 @@ func (s *Service) GetAncestors(ctx context.Context, id uint) ([]*model.Folder, e
 
 // MoveFolder 移动文件夹
 func (s *Service) MoveFolder(ctx context.Context, id uint, newParentID *uint) error {
-	folder, err := s.repo.FindByID(ctx, id)
+	return s.repo.WithTx(ctx, func(repo Repository) error {
+		return s.moveFolder(ctx, repo, id, newParentID, nil)
+	})
+}
+
+// MoveFolderWithVersion 移动文件夹，当前版本与 version 不一致时返回 ErrVersionConflict
+func (s *Service) MoveFolderWithVersion(ctx context.Context, id uint, newParentID *uint, version uint) error {
+	return s.repo.WithTx(ctx, func(repo Repository) error {
+		return s.moveFolder(ctx, repo, id, newParentID, &version)
+	})
+}
+
+// moveFolder 在给定仓储上移动文件夹及其子树
+func (s *Service) moveFolder(ctx context.Context, repo Repository, id uint, newParentID *uint, version *uint) error {
+	folder, err := repo.FindByID(ctx, id)
 	if err != nil {
 		return err
 	}
+	if err := checkVersion(folder, version); err != nil {
+		return err
+	}
 
 	// 检查是否移动到自己或子节点下
 	if newParentID != nil {
@@ -224,7 +278,7 @@ func (s *Service) MoveFolder(ctx context.Context, id uint, newParentID *uint) er
 			return ErrCircularReference
 		}
 
-		newParent, err := s.repo.FindByID(ctx, *newParentID)
+		newParent, err := repo.FindByID(ctx, *newParentID)
 		if err != nil {
 			return ErrParentNotFound
 		}
@@ -240,7 +294,7 @@ func (s *Service) MoveFolder(ctx context.Context, id uint, newParentID *uint) er
 	var newPath string
 
 	if newParentID != nil {
-		newParent, _ := s.repo.FindByID(ctx, *newParentID)
+		newParent, _ := repo.FindByID(ctx, *newParentID)
 		newDepth = newParent.Depth + 1
 		newPath = newParent.Path + fmt.Sprintf("%d/", folder.ID)
 	} else {
@@ -251,7 +305,7 @@ func (s *Service) MoveFolder(ctx context.Context, id uint, newParentID *uint) er
 	// 检查深度限制
 	if s.config.MaxDepth > 0 {
 		// 计算子树的最大深度
-		descendants, err := s.repo.FindByPath(ctx, folder.Path)
+		descendants, err := repo.FindByPath(ctx, folder.Path)
 		if err != nil {
 			return err
 		}
@@ -276,18 +330,16 @@ func (s *Service) MoveFolder(ctx context.Context, id uint, newParentID *uint) er
 	folder.Path = newPath
 
 	// 获取新的排序号
-	maxOrder, err := s.repo.FindMaxSortOrder(ctx, newParentID)
-	if err != nil {
+	if err := appendOrder(ctx, repo, s.config, folder, newParentID); err != nil {
 		return err
 	}
-	folder.SortOrder = maxOrder + 1
 
-	if err := s.repo.Update(ctx, folder); err != nil {
+	if err := repo.Update(ctx, folder); err != nil {
 		return err
 	}
 
 	// 更新所有子孙节点的 path 和 depth
-	if err := s.repo.UpdateChildrenPathAndDepth(ctx, oldPath, newPath, depthDiff); err != nil {
+	if err := repo.UpdateChildrenPathAndDepth(ctx, oldPath, newPath, depthDiff); err != nil {
 		return err
 	}
 
@@ -295,12 +347,47 @@ func (s *Service) MoveFolder(ctx context.Context, id uint, newParentID *uint) er
 }
 
 // ReorderFolder 调整排序
+// 整数排序号模式下 newOrder 为写入的排序号，同级中排序号不小于 newOrder 的其余节点依次后移一位，
+// 不会产生重复的排序号；排序键模式下 newOrder 表示在兄弟节点中的位置（从 1 开始）
 func (s *Service) ReorderFolder(ctx context.Context, id uint, newOrder int) error {
-	_, err := s.repo.FindByID(ctx, id)
-	if err != nil {
+	return s.repo.WithTx(ctx, func(repo Repository) error {
+		folder, err := repo.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		return s.reorderFolder(ctx, repo, folder, newOrder, nil)
+	})
+}
+
+// ReorderFolderWithVersion 调整排序，当前版本与 version 不一致时返回 ErrVersionConflict
+func (s *Service) ReorderFolderWithVersion(ctx context.Context, id uint, newOrder int, version uint) error {
+	return s.repo.WithTx(ctx, func(repo Repository) error {
+		folder, err := repo.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(folder, &version); err != nil {
+			return err
+		}
+		return s.reorderFolder(ctx, repo, folder, newOrder, &version)
+	})
+}
+
+// reorderFolder 在给定仓储上调整排序，version 非空时在写入排序值的同一语句中校验版本号
+func (s *Service) reorderFolder(ctx context.Context, repo Repository, folder *model.Folder, newOrder int, version *uint) error {
+	if s.config.OrderMode == OrderModeSortKey {
+		return s.moveToPosition(ctx, repo, folder, newOrder, version)
+	}
+
+	if err := repo.ShiftSortOrders(ctx, folder.ParentID, newOrder, folder.ID); err != nil {
 		return err
 	}
-	return s.repo.UpdateSortOrder(ctx, id, newOrder)
+	if version == nil {
+		return repo.UpdateSortOrder(ctx, folder.ID, newOrder)
+	}
+	folder.SortOrder = newOrder
+	folder.Version = *version
+	return repo.Update(ctx, folder)
 }
 
 // validateName 验证名称
@@ -315,14 +402,71 @@ func (s *Service) validateName(name string) error {
 	return nil
 }
 
+// checkVersion 校验期望版本号，version 为空时跳过
+func checkVersion(folder *model.Folder, version *uint) error {
+	if version != nil && folder.Version != *version {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// uniqueName 在同级下为 name 生成不冲突的名称，冲突时依次尝试 "name (1)"、"name (2)"...
+func uniqueName(ctx context.Context, repo Repository, name string, parentID *uint, excludeID *uint) (string, error) {
+	return uniqueNameWith(ctx, repo, name, parentID, excludeID, func(name string, n int) string {
+		return fmt.Sprintf("%s (%d)", name, n)
+	})
+}
+
+// uniqueNameWith 在同级下为 name 生成不冲突的名称，第 n 次冲突后尝试 format(name, n)
+func uniqueNameWith(ctx context.Context, repo Repository, name string, parentID *uint, excludeID *uint, format func(name string, n int) string) (string, error) {
+	candidate := name
+	for i := 1; ; i++ {
+		exists, err := repo.ExistsByNameAndParent(ctx, candidate, parentID, excludeID)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return candidate, nil
+		}
+		candidate = format(name, i)
+	}
+}
+
 // buildTree 构建树结构
 func buildTree(folders []*model.Folder, rootParentID *uint) []*model.FolderNode {
+	return buildTreeWith(folders, rootParentID, nil)
+}
+
+// countTree 根据已加载的完整树填充子节点数与子孙节点数
+func countTree(nodes []*model.FolderNode) []*model.FolderNode {
+	var count func(node *model.FolderNode) int
+	count = func(node *model.FolderNode) int {
+		node.ChildCount = len(node.Children)
+		node.HasChildren = node.ChildCount > 0
+		node.DescendantCount = 0
+		for _, child := range node.Children {
+			node.DescendantCount += 1 + count(child)
+		}
+		return node.DescendantCount
+	}
+	for _, node := range nodes {
+		count(node)
+	}
+	return nodes
+}
+
+// buildTreeWith 构建树形结构，decorate 不为 nil 时对每个节点调用以填充额外字段
+func buildTreeWith(folders []*model.Folder, rootParentID *uint, decorate func(f *model.Folder, node *model.FolderNode)) []*model.FolderNode {
 	nodeMap := make(map[uint]*model.FolderNode)
 	var roots []*model.FolderNode
 
 	// 创建所有节点
 	for _, f := range folders {
-		nodeMap[f.ID] = f.ToNode()
+		node := f.ToNode()
+		if decorate != nil {
+			decorate(f, node)
+		}
+		nodeMap[f.ID] = node
 	}
 
 	// 建立父子关系
@@ -2,7 +2,9 @@ package folder
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/KOMKZ/go-yogan-domain-folder/model"
 	"github.com/stretchr/testify/assert"
@@ -12,6 +14,18 @@ import (
 // MockRepository mock 实现
 type MockRepository struct {
 	mock.Mock
+	txCount    int  // WithTx 调用次数
+	rolledBack bool // 是否发生过回滚
+}
+
+// WithTx 直接在 mock 上执行 fn，并记录事务是否回滚
+func (m *MockRepository) WithTx(ctx context.Context, fn func(repo Repository) error) error {
+	m.txCount++
+	if err := fn(m); err != nil {
+		m.rolledBack = true
+		return err
+	}
+	return nil
 }
 
 func (m *MockRepository) Create(ctx context.Context, folder *model.Folder) error {
@@ -33,6 +47,11 @@ func (m *MockRepository) Delete(ctx context.Context, id uint) error {
 	return args.Error(0)
 }
 
+func (m *MockRepository) DeleteByIDs(ctx context.Context, ids []uint) error {
+	args := m.Called(ctx, ids)
+	return args.Error(0)
+}
+
 func (m *MockRepository) FindByID(ctx context.Context, id uint) (*model.Folder, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
@@ -81,6 +100,77 @@ func (m *MockRepository) FindAncestors(ctx context.Context, path string) ([]*mod
 	return args.Get(0).([]*model.Folder), args.Error(1)
 }
 
+func (m *MockRepository) FindByParentIDAfter(ctx context.Context, parentID *uint, after *ChildCursor, limit int) ([]*model.Folder, error) {
+	args := m.Called(ctx, parentID, after, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Folder), args.Error(1)
+}
+
+func (m *MockRepository) CountByParentID(ctx context.Context, parentID *uint) (int64, error) {
+	args := m.Called(ctx, parentID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepository) FindByPathMaxDepth(ctx context.Context, pathPrefix string, maxDepth int) ([]*model.Folder, error) {
+	args := m.Called(ctx, pathPrefix, maxDepth)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Folder), args.Error(1)
+}
+
+func (m *MockRepository) CountChildrenAtDepth(ctx context.Context, pathPrefix string, depth int) (map[uint]int, error) {
+	args := m.Called(ctx, pathPrefix, depth)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uint]int), args.Error(1)
+}
+
+func (m *MockRepository) CountDescendants(ctx context.Context, id uint) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepository) FindByNameAndParent(ctx context.Context, name string, parentID *uint) (*model.Folder, error) {
+	args := m.Called(ctx, name, parentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Folder), args.Error(1)
+}
+
+func (m *MockRepository) UpdatePinyin(ctx context.Context, id uint, full, initials string) error {
+	args := m.Called(ctx, id, full, initials)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindByIDs(ctx context.Context, ids []uint) ([]*model.Folder, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Folder), args.Error(1)
+}
+
+func (m *MockRepository) SearchByName(ctx context.Context, query *NameQuery) ([]*model.Folder, int64, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]*model.Folder), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepository) FindByNamePath(ctx context.Context, names []string) (*model.Folder, error) {
+	args := m.Called(ctx, names)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Folder), args.Error(1)
+}
+
 func (m *MockRepository) FindAll(ctx context.Context) ([]*model.Folder, error) {
 	args := m.Called(ctx)
 	if args.Get(0) == nil {
@@ -94,11 +184,26 @@ func (m *MockRepository) UpdateSortOrder(ctx context.Context, id uint, sortOrder
 	return args.Error(0)
 }
 
+func (m *MockRepository) ShiftSortOrders(ctx context.Context, parentID *uint, from int, excludeID uint) error {
+	args := m.Called(ctx, parentID, from, excludeID)
+	return args.Error(0)
+}
+
 func (m *MockRepository) FindMaxSortOrder(ctx context.Context, parentID *uint) (int, error) {
 	args := m.Called(ctx, parentID)
 	return args.Int(0), args.Error(1)
 }
 
+func (m *MockRepository) UpdateSortKey(ctx context.Context, id uint, sortKey string) error {
+	args := m.Called(ctx, id, sortKey)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindMaxSortKey(ctx context.Context, parentID *uint) (string, error) {
+	args := m.Called(ctx, parentID)
+	return args.String(0), args.Error(1)
+}
+
 func (m *MockRepository) UpdatePathAndDepth(ctx context.Context, id uint, path string, depth int) error {
 	args := m.Called(ctx, id, path, depth)
 	return args.Error(0)
@@ -119,6 +224,53 @@ func (m *MockRepository) HasChildren(ctx context.Context, id uint) (bool, error)
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *MockRepository) FindDeleted(ctx context.Context) ([]*model.Folder, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Folder), args.Error(1)
+}
+
+func (m *MockRepository) FindDeletedByID(ctx context.Context, id uint) (*model.Folder, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Folder), args.Error(1)
+}
+
+func (m *MockRepository) FindDeletedByPath(ctx context.Context, pathPrefix string) ([]*model.Folder, error) {
+	args := m.Called(ctx, pathPrefix)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Folder), args.Error(1)
+}
+
+func (m *MockRepository) FindByIDsUnscoped(ctx context.Context, ids []uint) ([]*model.Folder, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Folder), args.Error(1)
+}
+
+func (m *MockRepository) Restore(ctx context.Context, folder *model.Folder) error {
+	args := m.Called(ctx, folder)
+	return args.Error(0)
+}
+
+func (m *MockRepository) RestoreByIDs(ctx context.Context, ids []uint) error {
+	args := m.Called(ctx, ids)
+	return args.Error(0)
+}
+
+func (m *MockRepository) PurgeDeletedBefore(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 // TestCreateFolder_Success 测试创建根文件夹成功
 func TestCreateFolder_Success(t *testing.T) {
 	mockRepo := new(MockRepository)
@@ -423,6 +575,7 @@ func TestReorderFolder_Success(t *testing.T) {
 	}
 
 	mockRepo.On("FindByID", ctx, uint(1)).Return(folder, nil)
+	mockRepo.On("ShiftSortOrders", ctx, (*uint)(nil), 5, uint(1)).Return(nil)
 	mockRepo.On("UpdateSortOrder", ctx, uint(1), 5).Return(nil)
 
 	err := svc.ReorderFolder(ctx, 1, 5)
@@ -713,3 +866,196 @@ func TestBuildTree_MultiLevel(t *testing.T) {
 	assert.Len(t, result[0].Children[0].Children, 1) // 子1-1有1个孙节点
 	assert.Equal(t, "根2", result[1].Name)
 }
+
+// TestCreateFolder_RollbackOnCreateError 测试创建失败时回滚
+func TestCreateFolder_RollbackOnCreateError(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("ExistsByNameAndParent", ctx, "技术文章", (*uint)(nil), (*uint)(nil)).Return(false, nil)
+	mockRepo.On("FindMaxSortOrder", ctx, (*uint)(nil)).Return(0, nil)
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*model.Folder")).Return(errors.New("insert failed"))
+
+	folder, err := svc.CreateFolder(ctx, &CreateFolderInput{Name: "技术文章"})
+
+	assert.EqualError(t, err, "insert failed")
+	assert.Nil(t, folder)
+	assert.Equal(t, 1, mockRepo.txCount)
+	assert.True(t, mockRepo.rolledBack)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestCreateFolder_RollbackOnPathUpdateError 测试回写 path 失败时回滚
+func TestCreateFolder_RollbackOnPathUpdateError(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("ExistsByNameAndParent", ctx, "技术文章", (*uint)(nil), (*uint)(nil)).Return(false, nil)
+	mockRepo.On("FindMaxSortOrder", ctx, (*uint)(nil)).Return(0, nil)
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*model.Folder")).Return(nil)
+	mockRepo.On("Update", ctx, mock.AnythingOfType("*model.Folder")).Return(errors.New("update failed"))
+
+	folder, err := svc.CreateFolder(ctx, &CreateFolderInput{Name: "技术文章"})
+
+	assert.EqualError(t, err, "update failed")
+	assert.Nil(t, folder)
+	assert.True(t, mockRepo.rolledBack)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestUpdateFolder_RollbackOnUpdateError 测试更新失败时回滚
+func TestUpdateFolder_RollbackOnUpdateError(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo)
+	ctx := context.Background()
+
+	folderID := uint(1)
+	mockRepo.On("FindByID", ctx, folderID).Return(&model.Folder{ID: 1, Name: "旧名称", Path: "/1/"}, nil)
+	mockRepo.On("ExistsByNameAndParent", ctx, "新名称", (*uint)(nil), &folderID).Return(false, nil)
+	mockRepo.On("Update", ctx, mock.AnythingOfType("*model.Folder")).Return(errors.New("update failed"))
+
+	folder, err := svc.UpdateFolder(ctx, &UpdateFolderInput{ID: folderID, Name: "新名称"})
+
+	assert.EqualError(t, err, "update failed")
+	assert.Nil(t, folder)
+	assert.True(t, mockRepo.rolledBack)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestDeleteFolder_RollbackOnDeleteError 测试删除失败时回滚
+func TestDeleteFolder_RollbackOnDeleteError(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("FindByID", ctx, uint(1)).Return(&model.Folder{ID: 1, Path: "/1/"}, nil)
+	mockRepo.On("HasChildren", ctx, uint(1)).Return(false, nil)
+	mockRepo.On("Delete", ctx, uint(1)).Return(errors.New("delete failed"))
+
+	err := svc.DeleteFolder(ctx, 1)
+
+	assert.EqualError(t, err, "delete failed")
+	assert.True(t, mockRepo.rolledBack)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestMoveFolder_RollbackOnUpdateError 测试移动时更新节点失败回滚
+func TestMoveFolder_RollbackOnUpdateError(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo)
+	ctx := context.Background()
+
+	folder := &model.Folder{ID: 2, Name: "Go语言", Depth: 0, Path: "/2/"}
+	mockRepo.On("FindByID", ctx, uint(2)).Return(folder, nil)
+	mockRepo.On("FindByPath", ctx, "/2/").Return([]*model.Folder{folder}, nil)
+	mockRepo.On("FindMaxSortOrder", ctx, (*uint)(nil)).Return(0, nil)
+	mockRepo.On("Update", ctx, mock.AnythingOfType("*model.Folder")).Return(errors.New("update failed"))
+
+	err := svc.MoveFolder(ctx, 2, nil)
+
+	assert.EqualError(t, err, "update failed")
+	assert.True(t, mockRepo.rolledBack)
+	mockRepo.AssertNotCalled(t, "UpdateChildrenPathAndDepth", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestMoveFolder_RollbackOnChildrenUpdateError 测试移动时更新子孙失败回滚
+func TestMoveFolder_RollbackOnChildrenUpdateError(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo)
+	ctx := context.Background()
+
+	newParentID := uint(1)
+	mockRepo.On("FindByID", ctx, uint(2)).Return(&model.Folder{ID: 2, Depth: 0, Path: "/2/"}, nil)
+	mockRepo.On("FindByID", ctx, newParentID).Return(&model.Folder{ID: 1, Depth: 0, Path: "/1/"}, nil)
+	mockRepo.On("FindByPath", ctx, "/2/").Return([]*model.Folder{}, nil)
+	mockRepo.On("FindMaxSortOrder", ctx, &newParentID).Return(0, nil)
+	mockRepo.On("Update", ctx, mock.AnythingOfType("*model.Folder")).Return(nil)
+	mockRepo.On("UpdateChildrenPathAndDepth", ctx, "/2/", "/1/2/", 1).Return(errors.New("batch update failed"))
+
+	err := svc.MoveFolder(ctx, 2, &newParentID)
+
+	assert.EqualError(t, err, "batch update failed")
+	assert.True(t, mockRepo.rolledBack)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestReorderFolder_RollbackOnUpdateError 测试排序失败时回滚
+func TestReorderFolder_RollbackOnUpdateError(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("FindByID", ctx, uint(1)).Return(&model.Folder{ID: 1}, nil)
+	mockRepo.On("ShiftSortOrders", ctx, (*uint)(nil), 3, uint(1)).Return(nil)
+	mockRepo.On("UpdateSortOrder", ctx, uint(1), 3).Return(errors.New("update failed"))
+
+	err := svc.ReorderFolder(ctx, 1, 3)
+
+	assert.EqualError(t, err, "update failed")
+	assert.True(t, mockRepo.rolledBack)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestUpdateFolder_VersionConflict 测试更新时版本不一致
+func TestUpdateFolder_VersionConflict(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("FindByID", ctx, uint(1)).Return(&model.Folder{ID: 1, Name: "旧名称", Version: 3}, nil)
+
+	stale := uint(2)
+	folder, err := svc.UpdateFolder(ctx, &UpdateFolderInput{ID: 1, Name: "新名称", Version: &stale})
+
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	assert.Nil(t, folder)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+// TestMoveFolderWithVersion_Conflict 测试移动时版本不一致
+func TestMoveFolderWithVersion_Conflict(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("FindByID", ctx, uint(2)).Return(&model.Folder{ID: 2, Path: "/2/", Version: 5}, nil)
+
+	err := svc.MoveFolderWithVersion(ctx, 2, nil, 4)
+
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+// TestReorderFolderWithVersion_Success 测试带版本号排序成功
+func TestReorderFolderWithVersion_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("FindByID", ctx, uint(1)).Return(&model.Folder{ID: 1, SortOrder: 1, Version: 2}, nil)
+	mockRepo.On("ShiftSortOrders", ctx, (*uint)(nil), 5, uint(1)).Return(nil)
+	mockRepo.On("Update", ctx, mock.MatchedBy(func(f *model.Folder) bool {
+		return f.ID == 1 && f.SortOrder == 5 && f.Version == 2
+	})).Return(nil)
+
+	err := svc.ReorderFolderWithVersion(ctx, 1, 5, 2)
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestReorderFolderWithVersion_Conflict 测试排序时版本不一致
+func TestReorderFolderWithVersion_Conflict(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("FindByID", ctx, uint(1)).Return(&model.Folder{ID: 1, Version: 2}, nil)
+
+	err := svc.ReorderFolderWithVersion(ctx, 1, 5, 1)
+
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,64 @@
+package folder
+
+import "strings"
+
+// 排序键字符集：0-9a-z，按字节序与常见排序规则下的字符串顺序一致
+const sortKeyDigits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// sortKeyBase 排序键进制
+const sortKeyBase = len(sortKeyDigits)
+
+// defaultMaxSortKeyLength 排序键默认最大长度，超过后自动重排
+const defaultMaxSortKeyLength = 32
+
+// sortKeyBetween 生成严格位于 a 与 b 之间的排序键
+// 排序键视为 36 进制小数的各位数字，a 为空表示下界，b 为空表示无上界；要求 a < b
+// 生成的键不以 '0' 结尾，因此总能继续在其前后插入
+func sortKeyBetween(a, b string) string {
+	var sb strings.Builder
+	upperOpen := b == ""
+	for i := 0; ; i++ {
+		lo := 0
+		if i < len(a) {
+			lo = strings.IndexByte(sortKeyDigits, a[i])
+		}
+		hi := sortKeyBase
+		if !upperOpen && i < len(b) {
+			hi = strings.IndexByte(sortKeyDigits, b[i])
+		}
+
+		if lo == hi {
+			sb.WriteByte(sortKeyDigits[lo])
+			continue
+		}
+		if mid := (lo + hi) / 2; mid > lo {
+			sb.WriteByte(sortKeyDigits[mid])
+			return sb.String()
+		}
+		// hi == lo+1：本位取 lo 后已小于 b，后续只需大于 a 的剩余部分
+		sb.WriteByte(sortKeyDigits[lo])
+		upperOpen = true
+	}
+}
+
+// evenSortKeys 为 n 个节点生成均匀分布的排序键
+func evenSortKeys(n int) []string {
+	width, space := 1, sortKeyBase
+	for space <= 2*n {
+		width++
+		space *= sortKeyBase
+	}
+	step := space / (n + 1)
+
+	keys := make([]string, n)
+	buf := make([]byte, width)
+	for i := range keys {
+		v := step * (i + 1)
+		for j := width - 1; j >= 0; j-- {
+			buf[j] = sortKeyDigits[v%sortKeyBase]
+			v /= sortKeyBase
+		}
+		keys[i] = strings.TrimRight(string(buf), "0")
+	}
+	return keys
+}
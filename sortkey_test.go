@@ -0,0 +1,74 @@
+package folder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSortKeyBetween 测试生成中间排序键
+func TestSortKeyBetween(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+	}{
+		{"empty", "", ""},
+		{"after", "i", ""},
+		{"before", "", "i"},
+		{"between", "a", "c"},
+		{"adjacent", "a", "b"},
+		{"prefix", "a", "a1"},
+		{"after_max", "zz", ""},
+		{"before_min", "", "01"},
+		{"deep", "a0i", "a1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := sortKeyBetween(tt.a, tt.b)
+			assert.Greater(t, key, tt.a)
+			if tt.b != "" {
+				assert.Less(t, key, tt.b)
+			}
+			assert.NotEqual(t, byte('0'), key[len(key)-1])
+		})
+	}
+}
+
+// TestSortKeyBetween_RepeatedInsert 测试反复插入到最前、最后和同一间隙
+func TestSortKeyBetween_RepeatedInsert(t *testing.T) {
+	first := sortKeyBetween("", "")
+	head, tail := first, first
+	for i := 0; i < 100; i++ {
+		next := sortKeyBetween("", head)
+		assert.Less(t, next, head)
+		head = next
+
+		next = sortKeyBetween(tail, "")
+		assert.Greater(t, next, tail)
+		tail = next
+	}
+
+	upper := tail
+	for i := 0; i < 100; i++ {
+		next := sortKeyBetween(first, upper)
+		assert.Greater(t, next, first)
+		assert.Less(t, next, upper)
+		upper = next
+	}
+}
+
+// TestEvenSortKeys 测试均匀分布的排序键
+func TestEvenSortKeys(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 17, 18, 100, 5000} {
+		keys := evenSortKeys(n)
+		assert.Len(t, keys, n)
+		for i := 1; i < len(keys); i++ {
+			assert.Less(t, keys[i-1], keys[i])
+		}
+		for _, k := range keys {
+			assert.NotEmpty(t, k)
+			assert.NotEqual(t, byte('0'), k[len(k)-1])
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package folder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/KOMKZ/go-yogan-domain-folder/model"
+)
+
+// TrashItem 回收站条目
+type TrashItem struct {
+	Folder     *model.Folder   `json:"folder"`
+	Breadcrumb []*model.Folder `json:"breadcrumb"` // 删除前的祖先链（根在前，可能包含已删除节点）
+}
+
+// RestoreOptions 恢复选项
+type RestoreOptions struct {
+	IncludeDescendants bool // 同时恢复回收站中的整棵子树
+}
+
+// RestoreResult 恢复结果
+type RestoreResult struct {
+	Folder      *model.Folder `json:"folder"`      // 恢复后的顶层文件夹
+	RestoredIDs []uint        `json:"restoredIds"` // 所有被恢复的节点
+}
+
+// ListTrash 列出回收站中的文件夹及其原有面包屑
+func (s *Service) ListTrash(ctx context.Context) ([]*TrashItem, error) {
+	folders, err := s.repo.FindDeleted(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 一次性批量查询所有祖先
+	breadcrumbs, err := resolveBreadcrumbs(folders, func(ids []uint) ([]*model.Folder, error) {
+		return s.repo.FindByIDsUnscoped(ctx, ids)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*TrashItem, 0, len(folders))
+	for i, f := range folders {
+		items = append(items, &TrashItem{Folder: f, Breadcrumb: breadcrumbs[i]})
+	}
+	return items, nil
+}
+
+// RestoreFolder 从回收站恢复文件夹
+// 原父节点已不存在时恢复到根节点，同级重名时自动重命名为 "name (n)"
+func (s *Service) RestoreFolder(ctx context.Context, id uint, opts *RestoreOptions) (*RestoreResult, error) {
+	if opts == nil {
+		opts = &RestoreOptions{}
+	}
+
+	var result *RestoreResult
+	err := s.repo.WithTx(ctx, func(repo Repository) error {
+		var err error
+		result, err = s.restoreFolder(ctx, repo, id, opts)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// restoreFolder 在给定仓储上恢复文件夹
+func (s *Service) restoreFolder(ctx context.Context, repo Repository, id uint, opts *RestoreOptions) (*RestoreResult, error) {
+	folder, err := repo.FindDeletedByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	restored := []*model.Folder{folder}
+	var descendantIDs []uint
+	if opts.IncludeDescendants {
+		deleted, err := repo.FindDeletedByPath(ctx, folder.Path)
+		if err != nil {
+			return nil, err
+		}
+		// 只恢复同一批删除的子孙：级联删除在一条语句中写入相同的删除时间，
+		// 在此之前单独删除的子孙仍留在回收站
+		for _, f := range deleted {
+			if f.ID == folder.ID || f.DeletedAt.Time.Before(folder.DeletedAt.Time) {
+				continue
+			}
+			restored = append(restored, f)
+			descendantIDs = append(descendantIDs, f.ID)
+		}
+	}
+
+	// 确定恢复位置
+	parentID := folder.ParentID
+	newDepth := 0
+	newPath := fmt.Sprintf("/%d/", folder.ID)
+	if parentID != nil {
+		parent, err := repo.FindByID(ctx, *parentID)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			parentID = nil
+		case err != nil:
+			return nil, err
+		default:
+			newDepth = parent.Depth + 1
+			newPath = parent.Path + fmt.Sprintf("%d/", folder.ID)
+		}
+	}
+
+	// 检查深度限制
+	if s.config.MaxDepth > 0 {
+		maxChildDepth := 0
+		for _, d := range restored {
+			if relativeDepth := d.Depth - folder.Depth; relativeDepth > maxChildDepth {
+				maxChildDepth = relativeDepth
+			}
+		}
+		if newDepth+maxChildDepth >= s.config.MaxDepth {
+			return nil, ErrMaxDepthExceeded
+		}
+	}
+
+	name, err := uniqueName(ctx, repo, folder.Name, parentID, &folder.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	oldPath := folder.Path
+	depthDiff := newDepth - folder.Depth
+
+	// 先以新名称和位置恢复自身，避免与同级名称唯一索引冲突
+	folder.Name = name
+	s.indexPinyin(folder)
+	folder.ParentID = parentID
+	folder.Depth = newDepth
+	folder.Path = newPath
+	if err := appendOrder(ctx, repo, s.config, folder, parentID); err != nil {
+		return nil, err
+	}
+	if err := repo.Restore(ctx, folder); err != nil {
+		return nil, err
+	}
+	if err := repo.RestoreByIDs(ctx, descendantIDs); err != nil {
+		return nil, err
+	}
+
+	if oldPath != newPath || depthDiff != 0 {
+		if err := repo.UpdateChildrenPathAndDepth(ctx, oldPath, newPath, depthDiff); err != nil {
+			return nil, err
+		}
+	}
+
+	return &RestoreResult{Folder: folder, RestoredIDs: append([]uint{folder.ID}, descendantIDs...)}, nil
+}
+
+// PurgeTrash 永久删除在回收站中超过 retention 的文件夹，返回删除条数
+func (s *Service) PurgeTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	var purged int64
+	err := s.repo.WithTx(ctx, func(repo Repository) error {
+		var err error
+		purged, err = repo.PurgeDeletedBefore(ctx, time.Now().Add(-retention))
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return purged, nil
+}
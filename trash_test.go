@@ -0,0 +1,211 @@
+package folder
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestListTrash_Breadcrumb 测试回收站列表包含原有面包屑
+func TestListTrash_Breadcrumb(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	root := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &root.ID)
+	conc := mustCreateFolder(t, svc, "并发", &golang.ID)
+
+	_, err := svc.DeleteFolderWithOptions(ctx, golang.ID, &DeleteOptions{Strategy: DeleteCascade})
+	require.NoError(t, err)
+
+	items, err := svc.ListTrash(ctx)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+
+	byID := make(map[uint]*TrashItem)
+	for _, item := range items {
+		byID[item.Folder.ID] = item
+	}
+	require.Len(t, byID[conc.ID].Breadcrumb, 2)
+	assert.Equal(t, "技术", byID[conc.ID].Breadcrumb[0].Name)
+	assert.Equal(t, "Go", byID[conc.ID].Breadcrumb[1].Name)
+	require.Len(t, byID[golang.ID].Breadcrumb, 1)
+	assert.Equal(t, "技术", byID[golang.ID].Breadcrumb[0].Name)
+}
+
+// TestRestoreFolder_Subtree 测试恢复整棵子树到原父节点
+func TestRestoreFolder_Subtree(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	root := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &root.ID)
+	conc := mustCreateFolder(t, svc, "并发", &golang.ID)
+
+	_, err := svc.DeleteFolderWithOptions(ctx, golang.ID, &DeleteOptions{Strategy: DeleteCascade})
+	require.NoError(t, err)
+
+	result, err := svc.RestoreFolder(ctx, golang.ID, &RestoreOptions{IncludeDescendants: true})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uint{golang.ID, conc.ID}, result.RestoredIDs)
+	assert.Equal(t, &root.ID, result.Folder.ParentID)
+	assert.Equal(t, golang.Path, result.Folder.Path)
+
+	restored, err := repo.FindByID(ctx, conc.ID)
+	require.NoError(t, err)
+	assert.Equal(t, conc.Path, restored.Path)
+
+	items, err := svc.ListTrash(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, items)
+}
+
+// TestRestoreFolder_SubtreeSkipsEarlierDeleted 测试恢复子树时不恢复此前单独删除的子孙
+func TestRestoreFolder_SubtreeSkipsEarlierDeleted(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	root := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &root.ID)
+	conc := mustCreateFolder(t, svc, "并发", &golang.ID)
+	draft := mustCreateFolder(t, svc, "草稿", &golang.ID)
+
+	require.NoError(t, svc.DeleteFolder(ctx, draft.ID))
+	time.Sleep(10 * time.Millisecond)
+	_, err := svc.DeleteFolderWithOptions(ctx, golang.ID, &DeleteOptions{Strategy: DeleteCascade})
+	require.NoError(t, err)
+
+	result, err := svc.RestoreFolder(ctx, golang.ID, &RestoreOptions{IncludeDescendants: true})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uint{golang.ID, conc.ID}, result.RestoredIDs)
+
+	_, err = repo.FindByID(ctx, draft.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	items, err := svc.ListTrash(ctx)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, draft.ID, items[0].Folder.ID)
+}
+
+// TestRestoreFolder_SingleLeavesDescendants 测试只恢复自身时子孙仍在回收站
+func TestRestoreFolder_SingleLeavesDescendants(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	root := mustCreateFolder(t, svc, "技术", nil)
+	conc := mustCreateFolder(t, svc, "并发", &root.ID)
+
+	_, err := svc.DeleteFolderWithOptions(ctx, root.ID, &DeleteOptions{Strategy: DeleteCascade})
+	require.NoError(t, err)
+
+	result, err := svc.RestoreFolder(ctx, root.ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{root.ID}, result.RestoredIDs)
+
+	_, err = repo.FindByID(ctx, conc.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// TestRestoreFolder_ParentGone 测试原父节点已删除时恢复到根节点
+func TestRestoreFolder_ParentGone(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	root := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &root.ID)
+	conc := mustCreateFolder(t, svc, "并发", &golang.ID)
+
+	_, err := svc.DeleteFolderWithOptions(ctx, root.ID, &DeleteOptions{Strategy: DeleteCascade})
+	require.NoError(t, err)
+
+	result, err := svc.RestoreFolder(ctx, golang.ID, &RestoreOptions{IncludeDescendants: true})
+	require.NoError(t, err)
+	assert.Nil(t, result.Folder.ParentID)
+	assert.Equal(t, 0, result.Folder.Depth)
+	assert.Equal(t, fmt.Sprintf("/%d/", golang.ID), result.Folder.Path)
+
+	restored, err := repo.FindByID(ctx, conc.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, restored.Depth)
+	assert.Equal(t, fmt.Sprintf("/%d/%d/", golang.ID, conc.ID), restored.Path)
+}
+
+// TestRestoreFolder_RenameOnConflict 测试恢复时同级重名自动重命名
+func TestRestoreFolder_RenameOnConflict(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	old := mustCreateFolder(t, svc, "技术", nil)
+	require.NoError(t, svc.DeleteFolder(ctx, old.ID))
+	mustCreateFolder(t, svc, "技术", nil)
+	mustCreateFolder(t, svc, "技术 (1)", nil)
+
+	result, err := svc.RestoreFolder(ctx, old.ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "技术 (2)", result.Folder.Name)
+}
+
+// TestRestoreFolder_NotInTrash 测试恢复未删除的文件夹
+func TestRestoreFolder_NotInTrash(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+
+	f := mustCreateFolder(t, svc, "技术", nil)
+
+	_, err := svc.RestoreFolder(context.Background(), f.ID, nil)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// TestPurgeTrash 测试按保留期永久删除
+func TestPurgeTrash(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewGormRepository(db, testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	stale := mustCreateFolder(t, svc, "旧", nil)
+	fresh := mustCreateFolder(t, svc, "新", nil)
+	require.NoError(t, svc.DeleteFolder(ctx, stale.ID))
+	require.NoError(t, svc.DeleteFolder(ctx, fresh.ID))
+	require.NoError(t, db.Table(testTableName).Unscoped().
+		Where("id = ?", stale.ID).
+		Update("deleted_at", time.Now().Add(-48*time.Hour)).Error)
+
+	purged, err := svc.PurgeTrash(ctx, 24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	items, err := svc.ListTrash(ctx)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, fresh.ID, items[0].Folder.ID)
+}
+
+// TestDeleteFolder_IgnoresTrashedChildren 测试回收站中的子节点不阻止删除和重名
+func TestDeleteFolder_IgnoresTrashedChildren(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	root := mustCreateFolder(t, svc, "技术", nil)
+	child := mustCreateFolder(t, svc, "Go", &root.ID)
+	require.NoError(t, svc.DeleteFolder(ctx, child.ID))
+
+	mustCreateFolder(t, svc, "Go", &root.ID)
+	_, err := svc.DeleteFolderWithOptions(ctx, root.ID, &DeleteOptions{Strategy: DeleteCascade})
+	require.NoError(t, err)
+
+	items, err := svc.ListTrash(ctx)
+	require.NoError(t, err)
+	assert.Len(t, items, 3)
+}
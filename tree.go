@@ -0,0 +1,59 @@
+package folder
+
+import (
+	"context"
+
+	"github.com/KOMKZ/go-yogan-domain-folder/model"
+)
+
+// GetTreeWithOptions 按层级懒加载树：只返回 rootID 下 maxLevels 层节点（rootID 为 nil 时从根节点开始）
+// 每个节点标注 HasChildren 与 ChildCount（包括未加载的子节点）：
+// 最深一层节点的子节点数由一次分组查询得出，其余节点的子节点均已加载
+// maxLevels <= 0 表示不限制层数
+func (s *Service) GetTreeWithOptions(ctx context.Context, rootID *uint, maxLevels int) ([]*model.FolderNode, error) {
+	pathPrefix := "/"
+	baseDepth := 0
+	if rootID != nil {
+		root, err := s.repo.FindByID(ctx, *rootID)
+		if err != nil {
+			return nil, err
+		}
+		pathPrefix = root.Path
+		baseDepth = root.Depth + 1
+	}
+
+	if maxLevels <= 0 {
+		folders, err := s.repo.FindByPath(ctx, pathPrefix)
+		if err != nil {
+			return nil, err
+		}
+		return countTree(buildTree(folders, rootID)), nil
+	}
+
+	maxDepth := baseDepth + maxLevels - 1
+	folders, err := s.repo.FindByPathMaxDepth(ctx, pathPrefix, maxDepth)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.repo.CountChildrenAtDepth(ctx, pathPrefix, maxDepth)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := buildTree(folders, rootID)
+	markChildCounts(tree, maxDepth, counts)
+	return tree, nil
+}
+
+// markChildCounts 填充 ChildCount 与 HasChildren：深度为 maxDepth 的节点取 counts，其余节点取已加载的子节点数
+func markChildCounts(nodes []*model.FolderNode, maxDepth int, counts map[uint]int) {
+	for _, node := range nodes {
+		if node.Depth >= maxDepth {
+			node.ChildCount = counts[node.ID]
+		} else {
+			node.ChildCount = len(node.Children)
+			markChildCounts(node.Children, maxDepth, counts)
+		}
+		node.HasChildren = node.ChildCount > 0
+	}
+}
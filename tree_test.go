@@ -0,0 +1,179 @@
+package folder
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/KOMKZ/go-yogan-domain-folder/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetTreeWithOptions_LimitsLevels 测试按层数加载并标注子节点信息
+func TestGetTreeWithOptions_LimitsLevels(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	tech := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &tech.ID)
+	mustCreateFolder(t, svc, "并发", &golang.ID)
+	mustCreateFolder(t, svc, "泛型", &golang.ID)
+	mustCreateFolder(t, svc, "Rust", &tech.ID)
+	mustCreateFolder(t, svc, "生活", nil)
+
+	tree, err := svc.GetTreeWithOptions(ctx, nil, 1)
+	require.NoError(t, err)
+	require.Len(t, tree, 2)
+	assert.Empty(t, tree[0].Children)
+	assert.True(t, tree[0].HasChildren)
+	assert.Equal(t, 2, tree[0].ChildCount)
+	assert.False(t, tree[1].HasChildren)
+	assert.Equal(t, 0, tree[1].ChildCount)
+
+	tree, err = svc.GetTreeWithOptions(ctx, nil, 2)
+	require.NoError(t, err)
+	require.Len(t, tree[0].Children, 2)
+	goNode := tree[0].Children[0]
+	assert.Equal(t, "Go", goNode.Name)
+	assert.Empty(t, goNode.Children)
+	assert.True(t, goNode.HasChildren)
+	assert.Equal(t, 2, goNode.ChildCount)
+	assert.False(t, tree[0].Children[1].HasChildren)
+
+	// 不限制层数
+	tree, err = svc.GetTreeWithOptions(ctx, nil, 0)
+	require.NoError(t, err)
+	assert.Len(t, tree[0].Children[0].Children, 2)
+}
+
+// TestGetTreeWithOptions_FromRoot 测试从指定节点展开
+func TestGetTreeWithOptions_FromRoot(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	tech := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &tech.ID)
+	mustCreateFolder(t, svc, "并发", &golang.ID)
+	mustCreateFolder(t, svc, "Rust", &tech.ID)
+
+	tree, err := svc.GetTreeWithOptions(ctx, &tech.ID, 1)
+	require.NoError(t, err)
+	require.Len(t, tree, 2)
+	assert.Equal(t, "Go", tree[0].Name)
+	assert.Empty(t, tree[0].Children)
+	assert.True(t, tree[0].HasChildren)
+	assert.Equal(t, 1, tree[0].ChildCount)
+
+	missing := uint(999)
+	_, err = svc.GetTreeWithOptions(ctx, &missing, 1)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// TestGetTreeWithOptions_IgnoresDeleted 测试已删除的子节点不计入
+func TestGetTreeWithOptions_IgnoresDeleted(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	tech := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &tech.ID)
+	require.NoError(t, svc.DeleteFolder(ctx, golang.ID))
+
+	tree, err := svc.GetTreeWithOptions(ctx, nil, 1)
+	require.NoError(t, err)
+	require.Len(t, tree, 1)
+	assert.False(t, tree[0].HasChildren)
+	assert.Equal(t, 0, tree[0].ChildCount)
+}
+
+// TestGetTreeWithOptions_ManyRoots 测试节点数超过 SQL 参数上限时仍可按层加载
+func TestGetTreeWithOptions_ManyRoots(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewGormRepository(db, testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	const total = 40000
+	folders := make([]*model.Folder, 0, total+1)
+	for i := 1; i <= total; i++ {
+		folders = append(folders, &model.Folder{ID: uint(i), Name: fmt.Sprintf("f%d", i), SortOrder: i, Path: fmt.Sprintf("/%d/", i)})
+	}
+	parentID := uint(1)
+	folders = append(folders, &model.Folder{ID: total + 1, Name: "child", ParentID: &parentID, Depth: 1, Path: fmt.Sprintf("/1/%d/", total+1)})
+	require.NoError(t, db.Table(testTableName).CreateInBatches(folders, 500).Error)
+
+	tree, err := svc.GetTreeWithOptions(ctx, nil, 1)
+	require.NoError(t, err)
+	require.Len(t, tree, total)
+	assert.Equal(t, 1, tree[0].ChildCount)
+	assert.True(t, tree[0].HasChildren)
+	assert.False(t, tree[1].HasChildren)
+
+	tree, err = svc.GetTreeWithOptions(ctx, nil, 0)
+	require.NoError(t, err)
+	require.Len(t, tree, total)
+	assert.Equal(t, 1, tree[0].DescendantCount)
+}
+
+// TestGetTree_Counts 测试完整树与子树填充子节点数和子孙节点数
+func TestGetTree_Counts(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	tech := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &tech.ID)
+	mustCreateFolder(t, svc, "并发", &golang.ID)
+	mustCreateFolder(t, svc, "泛型", &golang.ID)
+	mustCreateFolder(t, svc, "Rust", &tech.ID)
+	mustCreateFolder(t, svc, "生活", nil)
+
+	tree, err := svc.GetTree(ctx)
+	require.NoError(t, err)
+	require.Len(t, tree, 2)
+	assert.Equal(t, 2, tree[0].ChildCount)
+	assert.Equal(t, 4, tree[0].DescendantCount)
+	assert.True(t, tree[0].HasChildren)
+	assert.Equal(t, 2, tree[0].Children[0].DescendantCount)
+	assert.Equal(t, 0, tree[0].Children[1].DescendantCount)
+	assert.Equal(t, 0, tree[1].DescendantCount)
+	assert.False(t, tree[1].HasChildren)
+
+	sub, err := svc.GetSubTree(ctx, tech.ID)
+	require.NoError(t, err)
+	require.Len(t, sub, 2)
+	assert.Equal(t, 2, sub[0].ChildCount)
+	assert.Equal(t, 2, sub[0].DescendantCount)
+
+	full, err := svc.GetTreeWithOptions(ctx, nil, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 4, full[0].DescendantCount)
+}
+
+// TestCountDescendants 测试按路径前缀统计子孙节点
+func TestCountDescendants(t *testing.T) {
+	repo := NewGormRepository(newTestDB(t), testTableName)
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	tech := mustCreateFolder(t, svc, "技术", nil)
+	golang := mustCreateFolder(t, svc, "Go", &tech.ID)
+	mustCreateFolder(t, svc, "并发", &golang.ID)
+	deleted := mustCreateFolder(t, svc, "Rust", &tech.ID)
+	require.NoError(t, svc.DeleteFolder(ctx, deleted.ID))
+	mustCreateFolder(t, svc, "生活", nil)
+
+	count, err := svc.CountDescendants(ctx, tech.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	count, err = svc.CountDescendants(ctx, golang.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	_, err = svc.CountDescendants(ctx, 999)
+	assert.ErrorIs(t, err, ErrNotFound)
+}